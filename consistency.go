@@ -0,0 +1,239 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+// ConsistencyMode selects how reads that follow a write are kept from observing a
+// replica that has not applied the write yet.
+type ConsistencyMode string
+
+const (
+	// ConsistencyEventual routes reads to replicas regardless of earlier writes.
+	ConsistencyEventual ConsistencyMode = ""
+	// ConsistencyGTID makes a replica read wait until the replica has executed the GTIDs
+	// of the primary observed after the last write of the context, and sends the read to
+	// the primary if the replica does not catch up within Config.GTIDWaitTimeout.
+	ConsistencyGTID ConsistencyMode = "gtid"
+	// ConsistencySticky sends every read to the primary for Config.StickyWindow after the
+	// last write of the context. It does not depend on GTIDs.
+	ConsistencySticky ConsistencyMode = "sticky"
+)
+
+const (
+	// defaultGTIDWaitTimeout is the default time a replica read waits for the replica to
+	// execute the GTIDs of a previous write.
+	defaultGTIDWaitTimeout = time.Second
+	// defaultStickyWindow is the default time reads stay on the primary after a write.
+	defaultStickyWindow = 5 * time.Second
+)
+
+// validate checks that the mode is one of the defined consistency modes.
+//
+// Returns:
+//   - An error naming the unknown mode, or nil.
+func (m ConsistencyMode) validate() error {
+	switch m {
+	case ConsistencyEventual, ConsistencyGTID, ConsistencySticky:
+		return nil
+	default:
+		return fmt.Errorf("unknown consistency mode %q", m)
+	}
+}
+
+// trackerKey is the context key under which the write tracker is stored.
+type trackerKey struct{}
+
+// tracker records the writes made with a context, per primary connection.
+type tracker struct {
+	mu     sync.Mutex
+	states map[*resolver]*writeState
+}
+
+// writeState holds what is known about the writes made to one primary.
+type writeState struct {
+	written   bool                  // Whether a write was made with the context
+	lastWrite time.Time             // When the last write was made
+	dirty     bool                  // Whether a write was made since gtid was read
+	writes    uint64                // Number of writes made, to detect writes during a GTID read
+	gtid      string                // The GTID set executed by the primary after the last write
+	caughtUp  map[*replica]struct{} // Replicas known to have executed gtid
+}
+
+// WithConsistency returns a copy of ctx that tracks the writes made with it, so that later
+// reads made with the same context observe them on connections whose Config.Consistency
+// is not ConsistencyEventual. Attach it once per request, for example in an HTTP
+// middleware, and pass the context to GORM with db.WithContext.
+//
+// Parameters:
+//   - ctx: The parent context.
+//
+// Returns:
+//   - A context carrying a new write tracker.
+//
+// Example:
+//
+//	ctx := WithConsistency(r.Context())
+//	db.WithContext(ctx).Create(&order)
+//	db.WithContext(ctx).First(&order, order.ID) // observes the new order
+func WithConsistency(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trackerKey{}, &tracker{states: make(map[*resolver]*writeState)})
+}
+
+// state returns the write state of the statement's context for this resolver.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+//
+// Returns:
+//   - The write tracker and the state, or nil if consistency is disabled or the context
+//     does not track writes. The state must only be used while holding the tracker lock.
+func (r *resolver) state(db *gorm.DB) (*tracker, *writeState) {
+	if r.consistency == ConsistencyEventual || db.Statement.Context == nil {
+		return nil, nil
+	}
+
+	t, ok := db.Statement.Context.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.states[r]
+	if !ok {
+		st = &writeState{caughtUp: make(map[*replica]struct{})}
+		t.states[r] = st
+	}
+
+	return t, st
+}
+
+// wrote records that the statement writes to the primary.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+func (r *resolver) wrote(db *gorm.DB) {
+	t, st := r.state(db)
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st.written = true
+	st.lastWrite = time.Now()
+	st.dirty = true
+	st.writes++
+}
+
+// consistent reports whether the replica may serve the read without missing a write made
+// earlier with the same context.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+//   - rep: The replica selected for the read.
+//
+// Returns:
+//   - true if the replica may serve the read, false if it must go to the primary.
+func (r *resolver) consistent(db *gorm.DB, rep *replica) bool {
+	t, st := r.state(db)
+	if t == nil {
+		return true
+	}
+
+	switch r.consistency {
+	case ConsistencyGTID:
+		return r.waitGTID(db.Statement.Context, t, st, rep)
+	case ConsistencySticky:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		return !st.written || time.Since(st.lastWrite) > r.stickyWindow
+	default:
+		return true
+	}
+}
+
+// waitGTID waits until the replica has executed the GTID set of the primary observed after
+// the last write. The GTID set is read from the primary on the first replica read after a
+// write, so that it includes transactions committed since the write was made.
+//
+// Parameters:
+//   - ctx: The context of the read.
+//   - t: The write tracker of the context.
+//   - st: The write state of the context for this resolver.
+//   - rep: The replica selected for the read.
+//
+// Returns:
+//   - true if the replica has caught up, false if the read must go to the primary.
+func (r *resolver) waitGTID(ctx context.Context, t *tracker, st *writeState, rep *replica) bool {
+	t.mu.Lock()
+	dirty, writes := st.dirty, st.writes
+	t.mu.Unlock()
+
+	// Read the GTID set without holding the tracker, which is shared by the whole request
+	var fetched string
+	if dirty {
+		if err := r.primary.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&fetched); err != nil {
+			r.log.Warn(ctx, "read GTID set of primary: %v, reading from primary", err)
+			return false
+		}
+	}
+
+	t.mu.Lock()
+	// A write made while the GTID set was read may be missing from it, so the set is only
+	// stored if no write was made in the meantime
+	if dirty && st.writes == writes {
+		st.dirty = false
+		if fetched != st.gtid {
+			st.gtid = fetched
+			st.caughtUp = make(map[*replica]struct{})
+		}
+	}
+
+	gtid, written := st.gtid, st.written
+	if dirty {
+		gtid = fetched
+	}
+	_, caughtUp := st.caughtUp[rep]
+	caughtUp = caughtUp && gtid == st.gtid
+	t.mu.Unlock()
+
+	if !written || caughtUp {
+		return true
+	}
+
+	if gtid == "" {
+		// The primary does not assign GTIDs, so the replica position cannot be checked
+		return false
+	}
+
+	var timedOut sql.NullInt64
+	err := rep.sqlDB.QueryRowContext(ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", gtid, r.gtidWaitTimeout.Seconds()).Scan(&timedOut)
+	if err != nil {
+		r.log.Warn(ctx, "wait for GTID set on replica %q (%s): %v, reading from primary", rep.name, rep.host, err)
+		return false
+	}
+
+	if !timedOut.Valid || timedOut.Int64 != 0 {
+		r.log.Info(ctx, "replica %q (%s) did not execute GTID set %s within %s, reading from primary",
+			rep.name, rep.host, gtid, r.gtidWaitTimeout)
+		return false
+	}
+
+	t.mu.Lock()
+	if st.gtid == gtid {
+		st.caughtUp[rep] = struct{}{}
+	}
+	t.mu.Unlock()
+
+	return true
+}
@@ -0,0 +1,194 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gtidConn is a driver connection answering every query with a GTID set once released.
+type gtidConn struct {
+	stubConn
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c gtidConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	close(c.started)
+	<-c.release
+	return &gtidRows{}, nil
+}
+
+func (c gtidConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c gtidConn) Driver() driver.Driver                        { return nil }
+
+// gtidRows is a single row holding a GTID set.
+type gtidRows struct{ done bool }
+
+func (r *gtidRows) Columns() []string { return []string{"gtid"} }
+func (r *gtidRows) Close() error      { return nil }
+func (r *gtidRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"
+	return nil
+}
+
+func newConsistentResolver(t *testing.T, mode ConsistencyMode) (*gorm.DB, *resolver) {
+	t.Helper()
+
+	cfg := Config{
+		User:          "user",
+		Host:          "127.0.0.1:1",
+		DBName:        "shop",
+		Timeout:       time.Second,
+		Replicas:      []Config{{Host: "127.0.0.2:1"}},
+		ReplicaPolicy: ReplicaPolicyRoundRobin,
+		Consistency:   mode,
+		GormConfig:    &gorm.Config{Logger: gormlogger.Discard},
+	}
+
+	db, err := New(WithConfigs(cfg), WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = closeDB(db) })
+
+	return db.Session(&gorm.Session{DryRun: true}), db.Config.Plugins[resolverPluginName].(*resolver)
+}
+
+func TestConsistencyGTID(t *testing.T) {
+	db, r := newConsistentResolver(t, ConsistencyGTID)
+	rep := r.replicas[0]
+	ctx := WithConsistency(context.Background())
+
+	var products []Product
+	if got := db.WithContext(ctx).Find(&products).Statement.ConnPool; got != rep.pool {
+		t.Error("read before any write was not routed to the replica")
+	}
+
+	db.WithContext(ctx).Create(&Product{Code: "D42"})
+
+	// The GTID set of the unreachable primary cannot be read, so the read falls back to it
+	if got := db.WithContext(ctx).Find(&products).Statement.ConnPool; got != r.primary {
+		t.Error("read after a write was not routed to the primary")
+	}
+
+	if got := db.Find(&products).Statement.ConnPool; got != rep.pool {
+		t.Error("read without a tracking context was not routed to the replica")
+	}
+
+	tr, st := r.state(db.WithContext(ctx))
+	tr.mu.Lock()
+	st.dirty = false
+	st.gtid = "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"
+	st.caughtUp[rep] = struct{}{}
+	tr.mu.Unlock()
+
+	if got := db.WithContext(ctx).Find(&products).Statement.ConnPool; got != rep.pool {
+		t.Error("read was not routed to a replica that executed the GTID set")
+	}
+
+	db.WithContext(ctx).Exec("UPDATE products SET price = 1")
+	if !st.dirty {
+		t.Error("raw write was not tracked")
+	}
+}
+
+func TestConsistencyEventual(t *testing.T) {
+	db, r := newConsistentResolver(t, ConsistencyEventual)
+	ctx := WithConsistency(context.Background())
+
+	db.WithContext(ctx).Create(&Product{Code: "D42"})
+
+	var products []Product
+	if got := db.WithContext(ctx).Find(&products).Statement.ConnPool; got != r.replicas[0].pool {
+		t.Error("read was not routed to the replica")
+	}
+}
+
+func TestConsistencyUnknownMode(t *testing.T) {
+	cfg := Config{User: "user", Host: "127.0.0.1:1", DBName: "shop",
+		Replicas: []Config{{Host: "127.0.0.2:1"}}, Consistency: "GTID"}
+
+	if _, err := New(WithConfigs(cfg), WithLazyConnect()); err == nil || !strings.Contains(err.Error(), `"GTID"`) {
+		t.Errorf("expected an unknown consistency mode error, got %v", err)
+	}
+}
+
+func TestConsistencySticky(t *testing.T) {
+	db, r := newConsistentResolver(t, ConsistencySticky)
+	r.stickyWindow = 50 * time.Millisecond
+	ctx := WithConsistency(context.Background())
+
+	var products []Product
+	if got := db.WithContext(ctx).Find(&products).Statement.ConnPool; got != r.replicas[0].pool {
+		t.Error("read before any write was not routed to the replica")
+	}
+
+	db.WithContext(ctx).Model(&Product{}).Where("code = ?", "D42").Update("price", 200)
+
+	if got := db.WithContext(ctx).Find(&products).Statement.ConnPool; got != r.primary {
+		t.Error("read within the sticky window was not routed to the primary")
+	}
+
+	if got := db.WithContext(WithConsistency(context.Background())).Find(&products).Statement.ConnPool; got != r.replicas[0].pool {
+		t.Error("read with another context was not routed to the replica")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := db.WithContext(ctx).Find(&products).Statement.ConnPool; got != r.replicas[0].pool {
+		t.Error("read after the sticky window was not routed to the replica")
+	}
+}
+
+func TestConsistencyGTIDReadUnlocked(t *testing.T) {
+	db, r := newConsistentResolver(t, ConsistencyGTID)
+
+	conn := gtidConn{started: make(chan struct{}), release: make(chan struct{})}
+	primary := sql.OpenDB(conn)
+	defer primary.Close()
+	r.primary = primary
+
+	stmt := db.WithContext(WithConsistency(context.Background()))
+	r.wrote(stmt)
+	tr, st := r.state(stmt)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.waitGTID(stmt.Statement.Context, tr, st, r.replicas[0])
+	}()
+	<-conn.started
+
+	// A write made while the GTID set is read must neither block nor be lost
+	wrote := make(chan struct{})
+	go func() {
+		r.wrote(stmt)
+		close(wrote)
+	}()
+
+	select {
+	case <-wrote:
+	case <-time.After(time.Second):
+		t.Fatal("write bookkeeping blocked on the GTID read")
+	}
+
+	close(conn.release)
+	<-done
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if !st.dirty || st.gtid != "" {
+		t.Errorf("GTID set read before the last write was stored: dirty %v, gtid %q", st.dirty, st.gtid)
+	}
+}
@@ -0,0 +1,257 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errAccessDenied is the MySQL error number returned when the server rejects the user or
+// password, which is what happens to a pool that still uses a rotated password.
+const errAccessDenied = 1045
+
+// Credentials holds the user and password used to authenticate a new connection.
+type Credentials struct {
+	User     string // Database user, the configured user is kept when empty
+	Password string // Database password
+}
+
+// CredentialProvider supplies the credentials of every new physical connection, so that
+// passwords can rotate without reopening the pool. Implementations must be safe for
+// concurrent use.
+type CredentialProvider interface {
+	// Credentials returns the credentials for a new connection. It may return cached values.
+	Credentials(ctx context.Context) (Credentials, error)
+	// Refresh returns the current credentials, bypassing any cache. It is called when the
+	// server rejects the credentials returned by Credentials.
+	Refresh(ctx context.Context) (Credentials, error)
+}
+
+// FileCredentials is a CredentialProvider reading the password from a file, such as a
+// mounted Kubernetes or Docker secret. The file is read again whenever its modification
+// time or size changes; trailing newlines are removed.
+type FileCredentials struct {
+	user string // User returned with the password, empty to keep the configured user
+	path string // Path of the password file
+
+	mu      sync.Mutex
+	creds   Credentials // Credentials read last
+	modTime time.Time   // Modification time of the file when it was read last
+	size    int64       // Size of the file when it was read last
+	loaded  bool        // Whether the file has been read
+}
+
+// NewFileCredentials returns a CredentialProvider reading the password from a file.
+//
+// Parameters:
+//   - user: The database user, or "" to keep Config.User.
+//   - path: The path of the file holding the password.
+//
+// Returns:
+//   - The provider. The file is not read until the first connection is dialed.
+//
+// Example:
+//
+//	cfg := Config{User: "app", Host: "127.0.0.1:3306", DBName: "orders",
+//	    Credentials: NewFileCredentials("", "/run/secrets/mysql-password")}
+func NewFileCredentials(user, path string) *FileCredentials {
+	return &FileCredentials{user: user, path: path}
+}
+
+// Credentials returns the credentials read from the file, reading it again if it has
+// changed since the last call.
+//
+// Parameters:
+//   - ctx: Unused, present to satisfy CredentialProvider.
+//
+// Returns:
+//   - The credentials.
+//   - An error if the file cannot be read.
+func (f *FileCredentials) Credentials(_ context.Context) (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	if f.loaded && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.creds, nil
+	}
+
+	return f.load(info)
+}
+
+// Refresh reads the file again, even if its modification time and size are unchanged.
+//
+// Parameters:
+//   - ctx: Unused, present to satisfy CredentialProvider.
+//
+// Returns:
+//   - The credentials.
+//   - An error if the file cannot be read.
+func (f *FileCredentials) Refresh(_ context.Context) (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return f.load(info)
+}
+
+// load reads the password file and caches its content. The caller must hold f.mu.
+//
+// Parameters:
+//   - info: The file information taken before reading, used to detect later changes.
+//
+// Returns:
+//   - The credentials.
+//   - An error if the file cannot be read.
+func (f *FileCredentials) load(info os.FileInfo) (Credentials, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	f.creds = Credentials{User: f.user, Password: strings.TrimRight(string(data), "\r\n")}
+	f.modTime, f.size, f.loaded = info.ModTime(), info.Size(), true
+
+	return f.creds, nil
+}
+
+// sameCredentials reports whether two providers supply the same credentials, so that
+// Manager.Reload keeps a pool whose configuration file only rebuilt an equal provider.
+//
+// Parameters:
+//   - a: The current provider.
+//   - b: The new provider.
+//
+// Returns:
+//   - true if both are nil, the same provider, or file providers of the same user and file.
+func sameCredentials(a, b CredentialProvider) bool {
+	if a == b {
+		return true
+	}
+
+	fa, ok := a.(*FileCredentials)
+	if !ok {
+		return false
+	}
+
+	fb, ok := b.(*FileCredentials)
+	return ok && fa.user == fb.user && fa.path == fb.path
+}
+
+// credentialConnector is a driver.Connector that asks a CredentialProvider for the
+// credentials of every new connection. When the server denies access, the credentials
+// are refreshed and the dial is retried once.
+type credentialConnector struct {
+	cfg      *gomysql.Config      // Driver configuration, User and Passwd are replaced for every dial
+	provider CredentialProvider   // Provider of the credentials, nil to use cfg as is
+	name     string               // Connection name used in log messages
+	log      gormlogger.Interface // Logger receiving credential refreshes
+}
+
+// credentialDialector returns a GORM dialector whose pool authenticates every new
+// connection with the credentials of Config.Credentials.
+//
+// Parameters:
+//   - dsn: The DSN of the configuration.
+//   - lazy: Whether the server must not be contacted.
+//
+// Returns:
+//   - The dialector.
+//   - An error if the DSN cannot be parsed.
+func (c *Config) credentialDialector(dsn string, lazy bool) (gorm.Dialector, error) {
+	dc, err := gomysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := &credentialConnector{cfg: dc, provider: c.Credentials, name: c.key(), log: c.logger()}
+
+	return mysql.New(mysql.Config{Conn: sql.OpenDB(cc), DSNConfig: dc, SkipInitializeWithVersion: lazy}), nil
+}
+
+// Connect dials a new connection with the current credentials, refreshing them and
+// dialing again if the server denies access.
+//
+// Parameters:
+//   - ctx: The context bounding the dial.
+//
+// Returns:
+//   - The new connection.
+//   - An error if the credentials cannot be obtained or the connection cannot be established.
+func (cc *credentialConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if cc.provider == nil {
+		return cc.dial(ctx, Credentials{User: cc.cfg.User, Password: cc.cfg.Passwd})
+	}
+
+	creds, err := cc.provider.Credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get credentials for %q: %w", cc.name, err)
+	}
+
+	conn, err := cc.dial(ctx, creds)
+	var myErr *gomysql.MySQLError
+	if !errors.As(err, &myErr) || myErr.Number != errAccessDenied {
+		return conn, err
+	}
+
+	// The password may have rotated since it was read
+	fresh, rerr := cc.provider.Refresh(ctx)
+	if rerr != nil {
+		return nil, errors.Join(err, fmt.Errorf("refresh credentials for %q: %w", cc.name, rerr))
+	}
+
+	if fresh == creds {
+		return nil, err
+	}
+
+	cc.log.Info(ctx, "database %q denied access, retrying with refreshed credentials", cc.name)
+
+	return cc.dial(ctx, fresh)
+}
+
+// Driver returns the MySQL driver.
+func (cc *credentialConnector) Driver() driver.Driver {
+	return gomysql.MySQLDriver{}
+}
+
+// dial opens a connection with the given credentials.
+//
+// Parameters:
+//   - ctx: The context bounding the dial.
+//   - creds: The credentials; an empty user keeps the configured one.
+//
+// Returns:
+//   - The new connection.
+//   - An error if the connection cannot be established.
+func (cc *credentialConnector) dial(ctx context.Context, creds Credentials) (driver.Conn, error) {
+	cfg := cc.cfg.Clone()
+	if creds.User != "" {
+		cfg.User = creds.User
+	}
+	cfg.Passwd = creds.Password
+
+	connector, err := gomysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return connector.Connect(ctx)
+}
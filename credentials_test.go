@@ -0,0 +1,249 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	gomysql "github.com/go-sql-driver/mysql"
+	gormlogger "gorm.io/gorm/logger"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// authServer is a fake MySQL server that accepts the handshake of a single user and
+// denies access to every other user.
+type authServer struct {
+	ln    net.Listener
+	user  string
+	mu    sync.Mutex
+	users []string // Users that attempted to log in
+}
+
+func newAuthServer(t *testing.T, user string) *authServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &authServer{ln: ln, user: user}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *authServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// Protocol 10 handshake with mysql_native_password and CLIENT_PROTOCOL_41
+	handshake := []byte{10}
+	handshake = append(handshake, "8.0.36\x00"...)
+	handshake = append(handshake, 1, 0, 0, 0)
+	handshake = append(handshake, "abcdefgh\x00"...)
+	handshake = append(handshake, 0x00, 0xa2, 45, 0x02, 0x00, 0x08, 0x00, 21)
+	handshake = append(handshake, make([]byte, 10)...)
+	handshake = append(handshake, "ijklmnopqrst\x00"...)
+	handshake = append(handshake, "mysql_native_password\x00"...)
+	if err := writePacket(conn, 0, handshake); err != nil {
+		return
+	}
+
+	resp, err := readPacket(conn)
+	if err != nil || len(resp) < 32 {
+		return
+	}
+
+	user := string(resp[32 : 32+bytes.IndexByte(resp[32:], 0)])
+	s.mu.Lock()
+	s.users = append(s.users, user)
+	s.mu.Unlock()
+
+	if user == s.user {
+		_ = writePacket(conn, 2, []byte{0x00, 0, 0, 0x02, 0, 0, 0})
+		_, _ = io.Copy(io.Discard, conn)
+		return
+	}
+
+	denied := []byte{0xff, errAccessDenied & 0xff, errAccessDenied >> 8}
+	denied = append(denied, "#28000Access denied"...)
+	_ = writePacket(conn, 2, denied)
+}
+
+func (s *authServer) attempts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.users...)
+}
+
+func writePacket(w io.Writer, seq byte, payload []byte) error {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	_, err := w.Write(append(header, payload...))
+	return err
+}
+
+func readPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, binary.LittleEndian.Uint32(append(header[:3], 0)))
+	_, err := io.ReadFull(r, payload)
+	return payload, err
+}
+
+// rotatingCredentials returns stale credentials until they are refreshed.
+type rotatingCredentials struct {
+	mu        sync.Mutex
+	current   Credentials
+	next      Credentials
+	refreshes int
+}
+
+func (r *rotatingCredentials) Credentials(context.Context) (Credentials, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current, nil
+}
+
+func (r *rotatingCredentials) Refresh(context.Context) (Credentials, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshes++
+	r.current = r.next
+	return r.current, nil
+}
+
+func TestCredentialConnectorRefreshesOnAccessDenied(t *testing.T) {
+	s := newAuthServer(t, "rotated")
+
+	cfg := gomysql.NewConfig()
+	cfg.Addr = s.ln.Addr().String()
+	cfg.Timeout = time.Second
+
+	provider := &rotatingCredentials{current: Credentials{User: "stale"}, next: Credentials{User: "rotated"}}
+	cc := &credentialConnector{cfg: cfg, provider: provider, name: "orders", log: gormlogger.Discard}
+
+	conn, err := cc.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+
+	if got := s.attempts(); len(got) != 2 || got[0] != "stale" || got[1] != "rotated" {
+		t.Errorf("login attempts = %v", got)
+	}
+
+	// Unchanged credentials are not retried
+	provider.next = Credentials{User: "revoked"}
+	provider.current = provider.next
+	_, err = cc.Connect(context.Background())
+
+	var myErr *gomysql.MySQLError
+	if !errors.As(err, &myErr) || myErr.Number != errAccessDenied {
+		t.Errorf("expected access denied, got %v", err)
+	}
+
+	if provider.refreshes != 2 || len(s.attempts()) != 3 {
+		t.Errorf("refreshes = %d, attempts = %v", provider.refreshes, s.attempts())
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	path := writeConfigFile(t, "password", "first\n")
+
+	f := NewFileCredentials("app", path)
+	creds, err := f.Credentials(context.Background())
+	if err != nil || creds != (Credentials{User: "app", Password: "first"}) {
+		t.Fatalf("Credentials() = %+v, %v", creds, err)
+	}
+
+	if err = os.WriteFile(path, []byte("second-password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if creds, _ = f.Credentials(context.Background()); creds.Password != "second-password" {
+		t.Errorf("changed file was not read again: %+v", creds)
+	}
+
+	// A rewrite within the same modification time and size is only seen by Refresh
+	info, _ := os.Stat(path)
+	if err = os.WriteFile(path, []byte("third-password-\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(path, info.ModTime(), info.ModTime())
+
+	if creds, _ = f.Credentials(context.Background()); creds.Password != "second-password" {
+		t.Errorf("unchanged file was read again: %+v", creds)
+	}
+
+	if creds, _ = f.Refresh(context.Background()); creds.Password != "third-password-" {
+		t.Errorf("Refresh() = %+v", creds)
+	}
+
+	if _, err = NewFileCredentials("", path+".missing").Credentials(context.Background()); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestConfigCredentials(t *testing.T) {
+	path := writeConfigFile(t, "mysql.yaml", `
+connections:
+  - host: 127.0.0.1:1
+    user: app
+    password_file: /run/secrets/mysql-password
+    db_name: orders
+    replicas:
+      - host: 127.0.0.2:1
+`)
+
+	fc, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfgs, err := fc.Configs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc := cfgs[0].Replicas[0].inherit(&cfgs[0], 0)
+	if rc.Credentials != cfgs[0].Credentials {
+		t.Error("replica did not inherit the credential provider")
+	}
+
+	again, _ := fc.Configs()
+	if diff := configDiff(cfgs[0], again[0]); len(diff) != 0 {
+		t.Errorf("configDiff() = %v for the same file", diff)
+	}
+
+	m, err := NewManager(WithConfigs(cfgs...), WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = m.Close(context.Background())
+
+	bad := writeConfigFile(t, "bad.yaml", "connections:\n  - host: h\n    db_name: d\n    password: a\n    password_file: b\n")
+	if _, err = LoadConfig(bad); err == nil {
+		t.Error("expected an error for password and password_file")
+	}
+}
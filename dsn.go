@@ -0,0 +1,196 @@
+package mysql
+
+import (
+	gomysql "github.com/go-sql-driver/mysql"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultCharset is the connection character set used when Config.Charset is empty.
+const defaultCharset = "utf8mb4"
+
+// FormatDSN builds the DSN (Data Source Name) string for the configuration.
+//
+// The DSN is produced by the go-sql-driver FormatDSN method, so parameter values are
+// escaped by the driver and credentials containing characters such as '@' or '/'
+// are handled correctly.
+//
+// When DSN is set it is returned unchanged. When TLS uses custom certificates, the
+// resulting tls.Config is registered with the driver and referenced from the DSN by name.
+//
+// Returns:
+//   - The DSN string that can be passed to the MySQL driver.
+//   - An error if the TLS settings cannot be applied.
+//
+// Example:
+//
+//	cfg := Config{User: "user", Password: "p@ss/word", Host: "127.0.0.1", Port: 3306, DBName: "db"}
+//	dsn, err := cfg.FormatDSN()
+func (c *Config) FormatDSN() (string, error) {
+	if c.DSN != "" {
+		return c.DSN, nil
+	}
+
+	dc, err := c.driverConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return dc.FormatDSN(), nil
+}
+
+// driverConfig converts the Config into a go-sql-driver Config.
+//
+// Returns:
+//   - A pointer to a gomysql.Config populated from the Config fields and defaults.
+//   - An error if the TLS settings cannot be applied.
+func (c *Config) driverConfig() (*gomysql.Config, error) {
+	dc := gomysql.NewConfig()
+	dc.User = c.User
+	dc.Passwd = c.Password
+	dc.Net = c.network()
+	dc.Addr = c.address()
+	dc.DBName = c.DBName
+	dc.ParseTime = true
+	dc.Loc = time.Local
+	dc.Timeout = c.Timeout
+	dc.ReadTimeout = c.ReadTimeout
+	dc.WriteTimeout = c.WriteTimeout
+
+	if c.Loc != nil {
+		dc.Loc = c.Loc
+	}
+
+	if c.Collation != "" {
+		dc.Collation = c.Collation
+	}
+
+	if c.MaxAllowedPacket > 0 {
+		dc.MaxAllowedPacket = c.MaxAllowedPacket
+	}
+
+	dc.Params = make(map[string]string, len(c.Params)+1)
+	for k, v := range c.Params {
+		dc.Params[k] = v
+	}
+
+	// Keep the historical utf8mb4 default unless the caller overrides it
+	if _, ok := dc.Params["charset"]; !ok {
+		dc.Params["charset"] = defaultCharset
+		if c.Charset != "" {
+			dc.Params["charset"] = c.Charset
+		}
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.apply(dc); err != nil {
+			return nil, err
+		}
+	}
+
+	return dc, nil
+}
+
+// dbName returns the database name of the configuration, reading it from DSN when
+// the raw DSN mode is used.
+//
+// Returns:
+//   - The database name, or an empty string if it cannot be determined.
+func (c *Config) dbName() string {
+	if c.DSN == "" {
+		return c.DBName
+	}
+
+	dc, err := gomysql.ParseDSN(c.DSN)
+	if err != nil {
+		return c.DBName
+	}
+
+	return dc.DBName
+}
+
+// host returns the address the configuration connects to, reading it from DSN when
+// the raw DSN mode is used. It is meant for error and log messages.
+//
+// Returns:
+//   - The network address, or an empty string if it cannot be determined.
+func (c *Config) host() string {
+	if c.DSN == "" && len(c.Hosts) > 0 {
+		return strings.Join(c.addresses(), ",")
+	}
+
+	if c.DSN == "" {
+		return c.address()
+	}
+
+	dc, err := gomysql.ParseDSN(c.DSN)
+	if err != nil {
+		return ""
+	}
+
+	return dc.Addr
+}
+
+// network returns the network type for the configuration.
+//
+// Returns:
+//   - Network, or "tcp" when it is empty.
+func (c *Config) network() string {
+	if c.Network == "" {
+		return "tcp"
+	}
+
+	return c.Network
+}
+
+// address returns the network address for the configuration, appending Port to
+// Host when Host does not already specify one. When Hosts is set, the address of the
+// first candidate is returned.
+//
+// Returns:
+//   - The address in "host:port" form, or Host unchanged when no port is configured
+//     or the network is not TCP.
+func (c *Config) address() string {
+	if len(c.Hosts) > 0 {
+		return c.addresses()[0]
+	}
+
+	return c.hostAddress(c.Host)
+}
+
+// addresses returns the network addresses of the candidate hosts in Hosts.
+//
+// Returns:
+//   - The addresses in the order of Hosts.
+func (c *Config) addresses() []string {
+	addrs := make([]string, len(c.Hosts))
+	for i, host := range c.Hosts {
+		addrs[i] = c.hostAddress(host)
+	}
+
+	return addrs
+}
+
+// hostAddress returns the network address of a host, appending Port when the host does
+// not already specify one.
+//
+// Parameters:
+//   - host: The host, optionally including the port.
+//
+// Returns:
+//   - The address in "host:port" form, or host unchanged when no port is configured or
+//     the network is not TCP.
+func (c *Config) hostAddress(host string) string {
+	if c.Port == 0 || c.network() != "tcp" {
+		return host
+	}
+
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(c.Port))
+}
@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	gomysql "github.com/go-sql-driver/mysql"
+	"testing"
+	"time"
+)
+
+func TestConfigFormatDSN(t *testing.T) {
+	cfg := Config{
+		User:        "user",
+		Password:    "p@ss/w:rd",
+		Host:        "127.0.0.1",
+		Port:        3307,
+		DBName:      "mysql_test",
+		Collation:   "utf8mb4_unicode_ci",
+		Timeout:     5 * time.Second,
+		ReadTimeout: 10 * time.Second,
+		Params:      map[string]string{"sql_mode": "'STRICT_ALL_TABLES'"},
+	}
+
+	dsn, err := cfg.FormatDSN()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := gomysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Passwd != cfg.Password {
+		t.Errorf("password = %q, want %q", parsed.Passwd, cfg.Password)
+	}
+
+	if parsed.Addr != "127.0.0.1:3307" {
+		t.Errorf("addr = %q, want %q", parsed.Addr, "127.0.0.1:3307")
+	}
+
+	if parsed.Collation != cfg.Collation || parsed.Timeout != cfg.Timeout || parsed.ReadTimeout != cfg.ReadTimeout {
+		t.Errorf("unexpected driver config: %+v", parsed)
+	}
+
+	if !parsed.ParseTime || parsed.Loc != time.Local {
+		t.Errorf("parseTime/loc defaults not applied: %+v", parsed)
+	}
+
+	if parsed.Params["charset"] != defaultCharset || parsed.Params["sql_mode"] != "'STRICT_ALL_TABLES'" {
+		t.Errorf("unexpected params: %v", parsed.Params)
+	}
+}
+
+func TestConfigAddress(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		want string
+	}{
+		{"127.0.0.1:33060", 0, "127.0.0.1:33060"},
+		{"127.0.0.1:33060", 3306, "127.0.0.1:33060"},
+		{"db.internal", 3306, "db.internal:3306"},
+		{"::1", 3306, "[::1]:3306"},
+		{"[::1]", 3306, "[::1]:3306"},
+	}
+
+	for _, tt := range tests {
+		cfg := Config{Host: tt.host, Port: tt.port}
+		if got := cfg.address(); got != tt.want {
+			t.Errorf("address(%q, %d) = %q, want %q", tt.host, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestConfigFormatDSNModes(t *testing.T) {
+	raw := Config{DSN: "user:pass@tcp(127.0.0.1:3306)/db?parseTime=true", Host: "ignored"}
+	if dsn, err := raw.FormatDSN(); err != nil || dsn != raw.DSN {
+		t.Errorf("FormatDSN() = %q, %v, want %q", dsn, err, raw.DSN)
+	}
+
+	socket := Config{Network: "unix", Host: "/var/run/mysqld/mysqld.sock", Port: 3306, DBName: "db"}
+	dsn, err := socket.FormatDSN()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := gomysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Net != "unix" || parsed.Addr != socket.Host {
+		t.Errorf("net/addr = %q/%q, want unix/%q", parsed.Net, parsed.Addr, socket.Host)
+	}
+}
@@ -0,0 +1,313 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileSuffix is appended to an environment variable name to read its value from a file,
+// e.g. MYSQL_PASSWORD_FILE=/run/secrets/mysql-password.
+const fileSuffix = "_FILE"
+
+// ConfigFromEnv builds a database configuration from environment variables.
+//
+// Variables are named after the prefix followed by an underscore and the field name:
+// NAME, HOST, PORT, NETWORK, USER, PASSWORD, DB_NAME, DSN, CHARSET, COLLATION, TIMEOUT,
+// READ_TIMEOUT, WRITE_TIMEOUT, MAX_ALLOWED_PACKET, PARAMS (URL query encoded),
+// TLS_MODE, TLS_CA_FILE, TLS_CERT_FILE, TLS_KEY_FILE and TLS_SERVER_NAME.
+// Every variable may instead be provided with a _FILE suffix pointing at a file that
+// holds the value, which is how mounted secrets are usually exposed.
+//
+// HOST, USER and DB_NAME are required unless DSN is set. HOST may list several
+// comma-separated candidate hosts, which are stored in Config.Hosts.
+//
+// Parameters:
+//   - prefix: The prefix of the variable names, e.g. "MYSQL".
+//
+// Returns:
+//   - A slice holding the single configuration, ready to be passed to WithConfigs.
+//   - An error listing every missing or invalid variable.
+//
+// Example:
+//
+//	cfgs, err := ConfigFromEnv("MYSQL")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	db, err := New(WithConfigs(cfgs...))
+func ConfigFromEnv(prefix string) ([]Config, error) {
+	cfg, err := configFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Config{cfg}, nil
+}
+
+// ConfigsFromEnv builds several named database configurations from environment variables.
+//
+// Each connection is discovered from a <prefix>_<NAME>_HOST or <prefix>_<NAME>_DSN variable
+// (or their _FILE variants) and read with the same variable names as ConfigFromEnv using
+// <prefix>_<NAME> as its prefix. Each configuration's Name is NAME in lower case, and
+// configurations are returned sorted by name.
+//
+// Parameters:
+//   - prefix: The prefix of the variable names, e.g. "MYSQL".
+//
+// Returns:
+//   - A slice of configurations, ready to be passed to WithConfigs.
+//   - An error if no connection is declared or any variable is missing or invalid.
+//
+// Example:
+//
+//	// MYSQL_ORDERS_HOST, MYSQL_ORDERS_USER, MYSQL_ORDERS_DB_NAME, MYSQL_USERS_DSN_FILE, ...
+//	cfgs, err := ConfigsFromEnv("MYSQL")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	dbs, err := NewMulti(WithConfigs(cfgs...))
+func ConfigsFromEnv(prefix string) ([]Config, error) {
+	names := envConnectionNames(prefix)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no database connections declared with prefix %s", prefix)
+	}
+
+	var errs []error
+	cfgs := make([]Config, 0, len(names))
+	for _, name := range names {
+		cfg, err := configFromEnv(prefix + "_" + name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		cfg.Name = strings.ToLower(name)
+
+		cfgs = append(cfgs, cfg)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return cfgs, nil
+}
+
+// envConnectionNames returns the sorted connection names declared in the environment
+// for the given prefix.
+//
+// Parameters:
+//   - prefix: The prefix of the variable names.
+//
+// Returns:
+//   - The distinct connection names, in upper case as they appear in the environment.
+func envConnectionNames(prefix string) []string {
+	seen := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		key = strings.TrimSuffix(key, fileSuffix)
+
+		rest, ok := strings.CutPrefix(key, prefix+"_")
+		if !ok {
+			continue
+		}
+
+		for _, suffix := range []string{"_HOST", "_DSN"} {
+			if name, ok := strings.CutSuffix(rest, suffix); ok && name != "" {
+				seen[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// configFromEnv reads a single configuration for the given prefix.
+//
+// Parameters:
+//   - prefix: The prefix of the variable names.
+//
+// Returns:
+//   - The configuration read from the environment.
+//   - An error listing every missing or invalid variable.
+func configFromEnv(prefix string) (Config, error) {
+	r := &envReader{prefix: prefix}
+
+	cfg := Config{Name: r.string("NAME"), DSN: r.string("DSN")}
+	if cfg.DSN == "" {
+		cfg.Host = r.required("HOST")
+		cfg.User = r.required("USER")
+		cfg.DBName = r.required("DB_NAME")
+	} else {
+		cfg.Host = r.string("HOST")
+		cfg.User = r.string("USER")
+		cfg.DBName = r.string("DB_NAME")
+	}
+
+	cfg.Password = r.string("PASSWORD")
+	cfg.Network = r.string("NETWORK")
+	cfg.Port = r.int("PORT")
+	cfg.Charset = r.string("CHARSET")
+	cfg.Collation = r.string("COLLATION")
+	cfg.Timeout = r.duration("TIMEOUT")
+	cfg.ReadTimeout = r.duration("READ_TIMEOUT")
+	cfg.WriteTimeout = r.duration("WRITE_TIMEOUT")
+	cfg.MaxAllowedPacket = r.int("MAX_ALLOWED_PACKET")
+	cfg.Params = r.params("PARAMS")
+
+	tlsCfg := TLSConfig{
+		Mode:       TLSMode(r.string("TLS_MODE")),
+		CAFile:     r.string("TLS_CA_FILE"),
+		CertFile:   r.string("TLS_CERT_FILE"),
+		KeyFile:    r.string("TLS_KEY_FILE"),
+		ServerName: r.string("TLS_SERVER_NAME"),
+	}
+	if tlsCfg != (TLSConfig{}) {
+		cfg.TLS = &tlsCfg
+	}
+
+	// A comma-separated HOST lists the candidate hosts of a primary/standby pair
+	if strings.Contains(cfg.Host, ",") {
+		for _, host := range strings.Split(cfg.Host, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				cfg.Hosts = append(cfg.Hosts, host)
+			}
+		}
+		cfg.Host = ""
+	}
+
+	if err := r.err(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// envReader reads prefixed environment variables and collects every problem it
+// encounters so they can be reported together.
+type envReader struct {
+	prefix  string
+	missing []string
+	errs    []error
+}
+
+// lookup returns the value of the variable, falling back to the contents of the file
+// named by its _FILE variant.
+//
+// Parameters:
+//   - key: The variable name without the prefix.
+//
+// Returns:
+//   - The value of the variable.
+//   - A boolean indicating whether the variable was set.
+func (r *envReader) lookup(key string) (string, bool) {
+	name := r.prefix + "_" + key
+	value, ok := os.LookupEnv(name)
+	path, fileOK := os.LookupEnv(name + fileSuffix)
+
+	switch {
+	case ok && fileOK:
+		r.errs = append(r.errs, fmt.Errorf("only one of %s and %s%s may be set", name, name, fileSuffix))
+		return "", false
+	case fileOK:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("read %s%s: %w", name, fileSuffix, err))
+			return "", false
+		}
+
+		return strings.TrimRight(string(data), "\r\n"), true
+	default:
+		return value, ok
+	}
+}
+
+// string returns the value of an optional variable.
+func (r *envReader) string(key string) string {
+	value, _ := r.lookup(key)
+	return value
+}
+
+// required returns the value of a required variable, recording it as missing when
+// it is unset or empty.
+func (r *envReader) required(key string) string {
+	value, _ := r.lookup(key)
+	if value == "" {
+		r.missing = append(r.missing, r.prefix+"_"+key)
+	}
+
+	return value
+}
+
+// int returns the value of an optional integer variable.
+func (r *envReader) int(key string) int {
+	value := r.string(key)
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("invalid integer in %s_%s: %w", r.prefix, key, err))
+	}
+
+	return n
+}
+
+// duration returns the value of an optional duration variable such as "5s".
+func (r *envReader) duration(key string) time.Duration {
+	value := r.string(key)
+	if value == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("invalid duration in %s_%s: %w", r.prefix, key, err))
+	}
+
+	return d
+}
+
+// params returns the value of an optional URL query encoded variable as a map.
+func (r *envReader) params(key string) map[string]string {
+	value := r.string(key)
+	if value == "" {
+		return nil
+	}
+
+	query, err := url.ParseQuery(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("invalid parameters in %s_%s: %w", r.prefix, key, err))
+		return nil
+	}
+
+	params := make(map[string]string, len(query))
+	for k := range query {
+		params[k] = query.Get(k)
+	}
+
+	return params
+}
+
+// err returns the combined error for all missing and invalid variables, or nil.
+func (r *envReader) err() error {
+	errs := r.errs
+	if len(r.missing) > 0 {
+		errs = append([]error{fmt.Errorf("missing required environment variables: %s", strings.Join(r.missing, ", "))}, errs...)
+	}
+
+	return errors.Join(errs...)
+}
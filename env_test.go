@@ -0,0 +1,93 @@
+package mysql
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secret, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("MYSQL_HOST", "db.internal")
+	t.Setenv("MYSQL_PORT", "3307")
+	t.Setenv("MYSQL_USER", "app")
+	t.Setenv("MYSQL_PASSWORD_FILE", secret)
+	t.Setenv("MYSQL_DB_NAME", "orders")
+	t.Setenv("MYSQL_TIMEOUT", "5s")
+	t.Setenv("MYSQL_PARAMS", "sql_mode=STRICT_ALL_TABLES")
+
+	cfgs, err := ConfigFromEnv("MYSQL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := cfgs[0]
+	if cfg.Host != "db.internal" || cfg.Port != 3307 || cfg.User != "app" || cfg.DBName != "orders" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if cfg.Password != "s3cr3t" {
+		t.Errorf("password = %q, want %q", cfg.Password, "s3cr3t")
+	}
+
+	if cfg.Timeout != 5*time.Second || cfg.Params["sql_mode"] != "STRICT_ALL_TABLES" {
+		t.Errorf("unexpected timeout or params: %+v", cfg)
+	}
+
+	if cfg.TLS != nil {
+		t.Errorf("TLS should not be set: %+v", cfg.TLS)
+	}
+}
+
+func TestConfigFromEnvMissing(t *testing.T) {
+	t.Setenv("MYSQL_MISSING_HOST", "db.internal")
+
+	_, err := ConfigFromEnv("MYSQL_MISSING")
+	if err == nil {
+		t.Fatal("expected an error for missing variables")
+	}
+
+	for _, name := range []string{"MYSQL_MISSING_USER", "MYSQL_MISSING_DB_NAME"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %s", err, name)
+		}
+	}
+}
+
+func TestConfigsFromEnv(t *testing.T) {
+	t.Setenv("APPDB_ORDERS_HOST", "orders.internal")
+	t.Setenv("APPDB_ORDERS_USER", "app")
+	t.Setenv("APPDB_ORDERS_DB_NAME", "orders")
+	t.Setenv("APPDB_USERS_DSN", "app:pass@tcp(users.internal:3306)/users")
+
+	cfgs, err := ConfigsFromEnv("APPDB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfgs) != 2 || cfgs[0].Name != "orders" || cfgs[1].Name != "users" || cfgs[1].DSN == "" {
+		t.Errorf("unexpected configs: %+v", cfgs)
+	}
+}
+
+func TestConfigFromEnvHosts(t *testing.T) {
+	t.Setenv("MYSQL_HOST", "db-a.internal:3306, db-b.internal:3306")
+	t.Setenv("MYSQL_USER", "app")
+	t.Setenv("MYSQL_DB_NAME", "orders")
+
+	cfgs, err := ConfigFromEnv("MYSQL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := cfgs[0]
+	if cfg.Host != "" || len(cfg.Hosts) != 2 || cfg.Hosts[1] != "db-b.internal:3306" {
+		t.Errorf("unexpected hosts: %q / %q", cfg.Host, cfg.Hosts)
+	}
+}
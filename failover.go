@@ -0,0 +1,439 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// errReadOnly is the MySQL error number returned when a statement is rejected because the
+// server runs with --read-only, which is what a demoted primary does after a failover.
+const errReadOnly = 1290
+
+// failoverConnector is a driver.Connector that dials the writable server among a list of
+// candidate hosts. When the current server becomes read-only or unreachable, the
+// candidates are probed again and the pool is re-pointed to the new writable server;
+// connections to the old server are discarded as they are returned to the pool.
+type failoverConnector struct {
+	cfg   *gomysql.Config      // Driver configuration, Addr is replaced for every dial
+	hosts []string             // Candidate addresses in order of preference
+	name  string               // Connection name used in log messages
+	log   gormlogger.Interface // Logger receiving failover events
+	creds CredentialProvider   // Provider of the credentials of every dial, nil to use cfg
+
+	mu       sync.RWMutex
+	addr     string        // Address of the current writable server, empty until probed
+	gen      atomic.Uint64 // Incremented every time addr changes
+	switchMu sync.Mutex    // Serializes probing
+	checking atomic.Bool   // Whether a background probe is running
+}
+
+// newFailoverConnector returns a connector dialing the writable server among hosts.
+//
+// Parameters:
+//   - cfg: The driver configuration.
+//   - hosts: The candidate addresses in order of preference.
+//   - name: The connection name used in log messages.
+//   - log: The logger receiving failover events.
+//
+// Returns:
+//   - The connector. No server is contacted until the first dial or probe.
+func newFailoverConnector(cfg *gomysql.Config, hosts []string, name string, log gormlogger.Interface) *failoverConnector {
+	return &failoverConnector{cfg: cfg, hosts: hosts, name: name, log: log}
+}
+
+// failoverDialector returns a GORM dialector whose pool always dials the writable server
+// among Config.Hosts.
+//
+// Parameters:
+//   - ctx: The context bounding the initial probe.
+//   - lazy: Whether to defer probing the hosts until the first connection is needed.
+//
+// Returns:
+//   - The dialector.
+//   - An error if the driver configuration is invalid or, unless lazy, no host is writable.
+func (c *Config) failoverDialector(ctx context.Context, lazy bool) (gorm.Dialector, error) {
+	dc, err := c.driverConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	fc := newFailoverConnector(dc, c.addresses(), c.key(), c.logger())
+	fc.creds = c.Credentials
+	if !lazy {
+		if _, err = fc.failover(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return mysql.New(mysql.Config{Conn: sql.OpenDB(fc), DSNConfig: dc, SkipInitializeWithVersion: lazy}), nil
+}
+
+// current returns the address of the writable server and its generation.
+func (fc *failoverConnector) current() (string, uint64) {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+
+	return fc.addr, fc.gen.Load()
+}
+
+// Connect dials the current writable server, probing the candidates first if it is not
+// known yet or cannot be reached.
+//
+// Parameters:
+//   - ctx: The context bounding the dial.
+//
+// Returns:
+//   - The new connection.
+//   - An error if no writable server can be reached.
+func (fc *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	addr, gen := fc.current()
+	if addr == "" {
+		if _, err := fc.failover(ctx, ""); err != nil {
+			return nil, err
+		}
+		addr, gen = fc.current()
+	}
+
+	conn, err := fc.dial(ctx, addr)
+	if err != nil {
+		next, ferr := fc.failover(ctx, addr)
+		if ferr != nil {
+			return nil, errors.Join(err, ferr)
+		}
+
+		if next == addr {
+			return nil, err
+		}
+
+		addr, gen = fc.current()
+		if conn, err = fc.dial(ctx, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	return &failoverConn{Conn: conn, fc: fc, gen: gen}, nil
+}
+
+// Driver returns the MySQL driver.
+func (fc *failoverConnector) Driver() driver.Driver {
+	return gomysql.MySQLDriver{}
+}
+
+// dial opens a connection to the given address.
+//
+// Parameters:
+//   - ctx: The context bounding the dial.
+//   - addr: The server address.
+//
+// Returns:
+//   - The new connection.
+//   - An error if the connection cannot be established.
+func (fc *failoverConnector) dial(ctx context.Context, addr string) (driver.Conn, error) {
+	return fc.connector(addr).Connect(ctx)
+}
+
+// connector returns a connector dialing the given address with the credentials of the
+// failover connector.
+//
+// Parameters:
+//   - addr: The server address.
+//
+// Returns:
+//   - The connector.
+func (fc *failoverConnector) connector(addr string) driver.Connector {
+	cfg := fc.cfg.Clone()
+	cfg.Addr = addr
+
+	return &credentialConnector{cfg: cfg, provider: fc.creds, name: fc.name, log: fc.log}
+}
+
+// writable reports whether the server at addr accepts writes.
+//
+// Parameters:
+//   - ctx: The context bounding the probe.
+//   - addr: The server address.
+//
+// Returns:
+//   - true if @@GLOBAL.read_only is off.
+//   - An error if the server cannot be queried.
+func (fc *failoverConnector) writable(ctx context.Context, addr string) (bool, error) {
+	db := sql.OpenDB(fc.connector(addr))
+	defer db.Close()
+
+	var readOnly bool
+	if err := db.QueryRowContext(ctx, "SELECT @@GLOBAL.read_only").Scan(&readOnly); err != nil {
+		return false, err
+	}
+
+	return !readOnly, nil
+}
+
+// failover probes the candidates in order and switches to the first writable one, unless
+// another caller already switched away from the given address.
+//
+// Parameters:
+//   - ctx: The context bounding the probes.
+//   - from: The address found to be read-only or unreachable, or "" if none is known.
+//
+// Returns:
+//   - The address of the writable server.
+//   - An error listing every candidate if none is writable.
+func (fc *failoverConnector) failover(ctx context.Context, from string) (string, error) {
+	fc.switchMu.Lock()
+	defer fc.switchMu.Unlock()
+
+	if addr, _ := fc.current(); addr != from {
+		return addr, nil
+	}
+
+	var errs []error
+	for _, addr := range fc.hosts {
+		ok, err := fc.writable(ctx, addr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+			continue
+		}
+
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: server is read-only", addr))
+			continue
+		}
+
+		if addr != from {
+			fc.mu.Lock()
+			fc.addr = addr
+			fc.gen.Add(1)
+			fc.mu.Unlock()
+
+			if from != "" {
+				fc.log.Warn(ctx, "database %q failed over from %s to %s", fc.name, from, addr)
+			}
+		}
+
+		return addr, nil
+	}
+
+	return "", fmt.Errorf("no writable host for %q: %w", fc.name, errors.Join(errs...))
+}
+
+// observe starts a failover when a statement error shows that the current server is no
+// longer the writable one. Read-only errors fail over synchronously so that the next
+// statement reaches the new server; connection errors are checked in the background.
+//
+// Parameters:
+//   - ctx: The context of the failed statement.
+//   - addr: The address of the server the statement ran on.
+//   - err: The statement error.
+func (fc *failoverConnector) observe(ctx context.Context, addr string, err error) {
+	if err == nil || errors.Is(err, driver.ErrSkip) {
+		return
+	}
+
+	var myErr *gomysql.MySQLError
+	if errors.As(err, &myErr) {
+		if myErr.Number == errReadOnly {
+			_, _ = fc.failover(context.WithoutCancel(ctx), addr)
+		}
+		return
+	}
+
+	var netErr net.Error
+	if !errors.Is(err, driver.ErrBadConn) && !errors.Is(err, gomysql.ErrInvalidConn) && !errors.As(err, &netErr) {
+		return
+	}
+
+	if !fc.checking.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer fc.checking.Store(false)
+		_, _ = fc.failover(context.Background(), addr)
+	}()
+}
+
+// failoverConn wraps a driver connection to report statement errors to its connector
+// and to be discarded once the connector has switched to another server.
+type failoverConn struct {
+	driver.Conn
+	fc  *failoverConnector
+	gen uint64 // Connector generation at dial time
+}
+
+// addr returns the address the connection was dialed to, or "" if the connector has
+// switched servers since.
+func (c *failoverConn) addr() string {
+	addr, gen := c.fc.current()
+	if gen != c.gen {
+		return ""
+	}
+
+	return addr
+}
+
+// stale reports whether the connector has switched servers since the connection was dialed.
+func (c *failoverConn) stale() bool {
+	return c.fc.gen.Load() != c.gen
+}
+
+// observe reports a statement error to the connector.
+func (c *failoverConn) observe(ctx context.Context, err error) {
+	if addr := c.addr(); addr != "" {
+		c.fc.observe(ctx, addr, err)
+	}
+}
+
+// PrepareContext prepares a statement and wraps it to report its errors.
+func (c *failoverConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		c.observe(ctx, err)
+		return nil, err
+	}
+
+	return &failoverStmt{Stmt: stmt, conn: c}, nil
+}
+
+// BeginTx starts a transaction.
+func (c *failoverConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	b, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		return c.Conn.Begin()
+	}
+
+	tx, err := b.BeginTx(ctx, opts)
+	c.observe(ctx, err)
+	return tx, err
+}
+
+// ExecContext executes a statement without preparing it.
+func (c *failoverConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	res, err := e.ExecContext(ctx, query, args)
+	c.observe(ctx, err)
+	return res, err
+}
+
+// QueryContext runs a query without preparing it.
+func (c *failoverConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	rows, err := q.QueryContext(ctx, query, args)
+	c.observe(ctx, err)
+	return rows, err
+}
+
+// Ping checks the connection.
+func (c *failoverConn) Ping(ctx context.Context) error {
+	if c.stale() {
+		return driver.ErrBadConn
+	}
+
+	p, ok := c.Conn.(driver.Pinger)
+	if !ok {
+		return nil
+	}
+
+	err := p.Ping(ctx)
+	c.observe(ctx, err)
+	return err
+}
+
+// ResetSession discards the connection if the connector has switched servers.
+func (c *failoverConn) ResetSession(ctx context.Context) error {
+	if c.stale() {
+		return driver.ErrBadConn
+	}
+
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// IsValid reports whether the connection may be returned to the pool.
+func (c *failoverConn) IsValid() bool {
+	if c.stale() {
+		return false
+	}
+
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+// CheckNamedValue converts statement arguments the way the wrapped driver does.
+func (c *failoverConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// failoverStmt wraps a prepared statement to report its errors to the connector.
+type failoverStmt struct {
+	driver.Stmt
+	conn *failoverConn
+}
+
+// ExecContext executes the prepared statement.
+func (s *failoverStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, errors.New("prepared statement does not support ExecContext")
+	}
+
+	res, err := e.ExecContext(ctx, args)
+	s.conn.observe(ctx, err)
+	return res, err
+}
+
+// QueryContext runs the prepared query.
+func (s *failoverStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, errors.New("prepared statement does not support QueryContext")
+	}
+
+	rows, err := q.QueryContext(ctx, args)
+	s.conn.observe(ctx, err)
+	return rows, err
+}
+
+// CheckNamedValue converts statement arguments the way the wrapped driver does.
+func (s *failoverStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+
+	return s.conn.CheckNamedValue(nv)
+}
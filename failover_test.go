@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubConn is a driver.Conn that does nothing.
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func TestConfigHosts(t *testing.T) {
+	cfg := Config{Hosts: []string{"db-a.internal", "db-b.internal:3307"}, Port: 3306, DBName: "orders"}
+
+	if got := cfg.host(); got != "db-a.internal:3306,db-b.internal:3307" {
+		t.Errorf("host() = %q", got)
+	}
+
+	dc, err := cfg.driverConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dc.Addr != "db-a.internal:3306" {
+		t.Errorf("Addr = %q, want the first candidate", dc.Addr)
+	}
+}
+
+func TestFailoverNoWritableHost(t *testing.T) {
+	cfg := Config{
+		Name:       "orders",
+		User:       "user",
+		Hosts:      []string{"127.0.0.1:1", "127.0.0.1:2"},
+		DBName:     "orders",
+		Timeout:    time.Second,
+		GormConfig: &gorm.Config{Logger: gormlogger.Discard},
+	}
+
+	_, err := New(WithConfigs(cfg))
+	if err == nil {
+		t.Fatal("expected an error when no host is reachable")
+	}
+
+	for _, want := range []string{"no writable host", "127.0.0.1:1", "127.0.0.1:2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	db, err := New(WithConfigs(cfg), WithLazyConnect())
+	if err != nil {
+		t.Fatal("lazy connect should not probe the hosts:", err)
+	}
+	defer func() { _ = closeDB(db) }()
+
+	if err = db.Exec("SELECT 1").Error; err == nil || !strings.Contains(err.Error(), "no writable host") {
+		t.Errorf("expected the first query to report the probe failure, got %v", err)
+	}
+}
+
+func TestFailoverConnStale(t *testing.T) {
+	fc := newFailoverConnector(gomysql.NewConfig(), nil, "orders", gormlogger.Discard)
+	fc.addr = "db-a.internal:3306"
+	fc.gen.Store(1)
+
+	conn := &failoverConn{Conn: stubConn{}, fc: fc, gen: 1}
+	if !conn.IsValid() || conn.ResetSession(context.Background()) != nil {
+		t.Error("current connection was reported stale")
+	}
+
+	// Errors unrelated to the server role must not trigger a probe
+	conn.observe(context.Background(), &gomysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	conn.observe(context.Background(), errors.New("syntax"))
+	if fc.checking.Load() {
+		t.Error("unrelated error started a failover check")
+	}
+
+	fc.gen.Store(2)
+	if conn.IsValid() {
+		t.Error("connection to the previous server was reported valid")
+	}
+
+	if err := conn.ResetSession(context.Background()); !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("ResetSession() = %v, want driver.ErrBadConn", err)
+	}
+}
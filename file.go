@@ -0,0 +1,492 @@
+package mysql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	sklogger "github.com/sk-pkg/logger"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that is written as a string such as "3h" or "500ms"
+// in configuration files.
+type Duration time.Duration
+
+// UnmarshalJSON parses a JSON string such as "3h" into the Duration.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"3h\": %w", err)
+	}
+
+	return d.parse(s)
+}
+
+// UnmarshalYAML parses a YAML scalar such as 3h into the Duration.
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	return d.parse(value.Value)
+}
+
+// parse sets the Duration from its string form.
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// FileConfig is the schema of a configuration file describing all database connections,
+// the global pool settings, the GORM flags and the logger.
+//
+// Example (YAML):
+//
+//	max_idle_conn: 10
+//	max_open_conn: 50
+//	conn_max_lifetime: 3h
+//	gorm:
+//	  skip_default_transaction: true
+//	logger:
+//	  level: warn
+//	  slow_threshold: 200ms
+//	connections:
+//	  - name: orders
+//	    host: 127.0.0.1:3306
+//	    user: app
+//	    password: secret
+//	    db_name: orders
+//	    max_open_conn: 200
+type FileConfig struct {
+	MaxIdleConn     int              `json:"max_idle_conn" yaml:"max_idle_conn"`
+	MaxOpenConn     int              `json:"max_open_conn" yaml:"max_open_conn"`
+	ConnMaxLifetime Duration         `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration         `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+	WarmConnections int              `json:"warm_connections" yaml:"warm_connections"`
+	Gorm            FileGormConfig   `json:"gorm" yaml:"gorm"`
+	Logger          *FileLogConfig   `json:"logger" yaml:"logger"`
+	Connections     []FileConnection `json:"connections" yaml:"connections"`
+}
+
+// FileGormConfig holds the GORM flags that can be set from a configuration file.
+type FileGormConfig struct {
+	SkipDefaultTransaction                   bool `json:"skip_default_transaction" yaml:"skip_default_transaction"`
+	PrepareStmt                              bool `json:"prepare_stmt" yaml:"prepare_stmt"`
+	DisableAutomaticPing                     bool `json:"disable_automatic_ping" yaml:"disable_automatic_ping"`
+	DisableForeignKeyConstraintWhenMigrating bool `json:"disable_foreign_key_constraint_when_migrating" yaml:"disable_foreign_key_constraint_when_migrating"`
+	AllowGlobalUpdate                        bool `json:"allow_global_update" yaml:"allow_global_update"`
+	QueryFields                              bool `json:"query_fields" yaml:"query_fields"`
+	TranslateError                           bool `json:"translate_error" yaml:"translate_error"`
+	CreateBatchSize                          int  `json:"create_batch_size" yaml:"create_batch_size"`
+}
+
+// FileLogConfig holds the logger settings that can be set from a configuration file.
+type FileLogConfig struct {
+	Level                     string   `json:"level" yaml:"level"`
+	SlowThreshold             Duration `json:"slow_threshold" yaml:"slow_threshold"`
+	IgnoreRecordNotFoundError *bool    `json:"ignore_record_not_found_error" yaml:"ignore_record_not_found_error"`
+}
+
+// FileTLSConfig holds the TLS settings of a connection in a configuration file.
+type FileTLSConfig struct {
+	Mode       string `json:"mode" yaml:"mode"`
+	CAFile     string `json:"ca_file" yaml:"ca_file"`
+	CertFile   string `json:"cert_file" yaml:"cert_file"`
+	KeyFile    string `json:"key_file" yaml:"key_file"`
+	ServerName string `json:"server_name" yaml:"server_name"`
+}
+
+// FileConnection describes a single connection in a configuration file. The pool
+// settings override the global ones when greater than 0, and the gorm section replaces
+// the global GORM flags for this connection.
+type FileConnection struct {
+	Name             string            `json:"name" yaml:"name"`
+	User             string            `json:"user" yaml:"user"`
+	Password         string            `json:"password" yaml:"password"`
+	PasswordFile     string            `json:"password_file" yaml:"password_file"`
+	Network          string            `json:"network" yaml:"network"`
+	Host             string            `json:"host" yaml:"host"`
+	Hosts            []string          `json:"hosts" yaml:"hosts"`
+	Port             int               `json:"port" yaml:"port"`
+	DBName           string            `json:"db_name" yaml:"db_name"`
+	DSN              string            `json:"dsn" yaml:"dsn"`
+	Charset          string            `json:"charset" yaml:"charset"`
+	Collation        string            `json:"collation" yaml:"collation"`
+	Loc              string            `json:"loc" yaml:"loc"`
+	Timeout          Duration          `json:"timeout" yaml:"timeout"`
+	ReadTimeout      Duration          `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout     Duration          `json:"write_timeout" yaml:"write_timeout"`
+	MaxAllowedPacket int               `json:"max_allowed_packet" yaml:"max_allowed_packet"`
+	Params           map[string]string `json:"params" yaml:"params"`
+	TLS              *FileTLSConfig    `json:"tls" yaml:"tls"`
+	MaxIdleConn      int               `json:"max_idle_conn" yaml:"max_idle_conn"`
+	MaxOpenConn      int               `json:"max_open_conn" yaml:"max_open_conn"`
+	ConnMaxLifetime  Duration          `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime  Duration          `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+	WarmConnections  int               `json:"warm_connections" yaml:"warm_connections"`
+	Gorm             *FileGormConfig   `json:"gorm" yaml:"gorm"`
+	Replicas         []FileConnection  `json:"replicas" yaml:"replicas"`
+	ReplicaPolicy    string            `json:"replica_policy" yaml:"replica_policy"`
+
+	MaxReplicaLag           Duration `json:"max_replica_lag" yaml:"max_replica_lag"`
+	ReplicaLagCheckInterval Duration `json:"replica_lag_check_interval" yaml:"replica_lag_check_interval"`
+	Consistency             string   `json:"consistency" yaml:"consistency"`
+	GTIDWaitTimeout         Duration `json:"gtid_wait_timeout" yaml:"gtid_wait_timeout"`
+	StickyWindow            Duration `json:"sticky_window" yaml:"sticky_window"`
+}
+
+// LoadConfig reads and validates a YAML (.yaml, .yml) or JSON (.json) configuration file.
+// Unknown keys are rejected so that typos do not silently fall back to defaults.
+//
+// Parameters:
+//   - path: The path of the configuration file.
+//
+// Returns:
+//   - A pointer to the parsed FileConfig.
+//   - An error if the file cannot be read, parsed or validated.
+//
+// Example:
+//
+//	fc, err := LoadConfig("config/mysql.yaml")
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fc := &FileConfig{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(fc)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(fc)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	if err = fc.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return fc, nil
+}
+
+// NewFromFile initializes the database connections described by a configuration file.
+// It is equivalent to calling NewMulti with the options returned by FileConfig.Options,
+// followed by any additional options.
+//
+// Parameters:
+//   - path: The path of the configuration file.
+//   - manager: The sklogger.Manager used for logging, or nil to log to standard output.
+//   - opts: Additional Option functions applied after the ones from the file.
+//
+// Returns:
+//   - A map with database names as keys and corresponding gorm.DB instances as values.
+//   - An error if the file is invalid or the initialization fails.
+//
+// Example:
+//
+//	dbs, err := NewFromFile("config/mysql.yaml", manager)
+func NewFromFile(path string, manager *sklogger.Manager, opts ...Option) (map[string]*gorm.DB, error) {
+	fc, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileOpts, err := fc.Options(manager)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMulti(append(fileOpts, opts...)...)
+}
+
+// Options converts the file configuration into Option functions.
+//
+// Parameters:
+//   - manager: The sklogger.Manager used for logging, or nil to log to standard output.
+//     The logger is only configured when the file has a logger section.
+//
+// Returns:
+//   - A slice of Option functions equivalent to the file configuration.
+//   - An error if a connection cannot be converted.
+func (fc *FileConfig) Options(manager *sklogger.Manager) ([]Option, error) {
+	cfgs, err := fc.Configs()
+	if err != nil {
+		return nil, err
+	}
+
+	gormCfg := fc.Gorm.config()
+	if fc.Logger != nil {
+		gormCfg.Logger = fc.Logger.logger(manager)
+	}
+
+	opts := []Option{WithConfigs(cfgs...), WithGormConfig(gormCfg)}
+	if fc.MaxIdleConn > 0 {
+		opts = append(opts, WithMaxIdleConn(fc.MaxIdleConn))
+	}
+
+	if fc.MaxOpenConn > 0 {
+		opts = append(opts, WithMaxOpenConn(fc.MaxOpenConn))
+	}
+
+	if fc.ConnMaxLifetime > 0 {
+		opts = append(opts, WithConnMaxLifetime(time.Duration(fc.ConnMaxLifetime)))
+	}
+
+	if fc.ConnMaxIdleTime > 0 {
+		opts = append(opts, WithConnMaxIdleTime(time.Duration(fc.ConnMaxIdleTime)))
+	}
+
+	if fc.WarmConnections > 0 {
+		opts = append(opts, WithWarmConnections(fc.WarmConnections))
+	}
+
+	return opts, nil
+}
+
+// Configs converts the connections of the file configuration into Configs, without the
+// top-level settings. Use it to pass a changed file to Manager.Reload.
+//
+// Returns:
+//   - The configurations of every connection.
+//   - An error if a connection cannot be converted.
+func (fc *FileConfig) Configs() ([]Config, error) {
+	cfgs := make([]Config, 0, len(fc.Connections))
+	for i := range fc.Connections {
+		cfg, err := fc.Connections[i].config()
+		if err != nil {
+			return nil, fmt.Errorf("connection %d: %w", i, err)
+		}
+
+		cfgs = append(cfgs, cfg)
+	}
+
+	return cfgs, nil
+}
+
+// config converts the GORM flags into a gorm.Config.
+//
+// Returns:
+//   - The resulting gorm.Config.
+func (fg *FileGormConfig) config() gorm.Config {
+	return gorm.Config{
+		SkipDefaultTransaction:                   fg.SkipDefaultTransaction,
+		PrepareStmt:                              fg.PrepareStmt,
+		DisableAutomaticPing:                     fg.DisableAutomaticPing,
+		DisableForeignKeyConstraintWhenMigrating: fg.DisableForeignKeyConstraintWhenMigrating,
+		AllowGlobalUpdate:                        fg.AllowGlobalUpdate,
+		QueryFields:                              fg.QueryFields,
+		TranslateError:                           fg.TranslateError,
+		CreateBatchSize:                          fg.CreateBatchSize,
+	}
+}
+
+// validate checks the file configuration for values that cannot be used.
+//
+// Returns:
+//   - An error describing every invalid value, or nil.
+func (fc *FileConfig) validate() error {
+	var errs []error
+	if len(fc.Connections) == 0 {
+		errs = append(errs, errors.New("at least one connection is required"))
+	}
+
+	if fc.Logger != nil && fc.Logger.Level != "" {
+		if _, ok := parseLogLevel(fc.Logger.Level); !ok {
+			errs = append(errs, fmt.Errorf("unknown logger level %q", fc.Logger.Level))
+		}
+	}
+
+	seen := make(map[string]bool, len(fc.Connections))
+	for i, conn := range fc.Connections {
+		cfg, err := conn.config()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("connection %d: %w", i, err))
+			continue
+		}
+
+		if name := cfg.key(); seen[name] {
+			errs = append(errs, fmt.Errorf("connection %d: duplicate connection name %q", i, name))
+		} else {
+			seen[name] = true
+		}
+
+		if conn.Password != "" && conn.PasswordFile != "" {
+			errs = append(errs, fmt.Errorf("connection %d: password and password_file are mutually exclusive", i))
+		}
+
+		if conn.DSN == "" && ((conn.Host == "" && len(conn.Hosts) == 0) || conn.DBName == "") {
+			errs = append(errs, fmt.Errorf("connection %d: host (or hosts) and db_name are required unless dsn is set", i))
+		}
+
+		if err = cfg.ReplicaPolicy.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("connection %d: %w, expected %q, %q or %q",
+				i, err, ReplicaPolicyRandom, ReplicaPolicyRoundRobin, ReplicaPolicyLeastInUse))
+		}
+
+		if err = cfg.Consistency.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("connection %d: %w, expected %q, %q or empty for eventual consistency",
+				i, err, ConsistencyGTID, ConsistencySticky))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// config converts the file connection into a Config.
+//
+// Returns:
+//   - The resulting Config.
+//   - An error if the location cannot be loaded.
+func (fc *FileConnection) config() (Config, error) {
+	cfg := Config{
+		Name:             fc.Name,
+		User:             fc.User,
+		Password:         fc.Password,
+		Network:          fc.Network,
+		Host:             fc.Host,
+		Hosts:            fc.Hosts,
+		Port:             fc.Port,
+		DBName:           fc.DBName,
+		DSN:              fc.DSN,
+		Charset:          fc.Charset,
+		Collation:        fc.Collation,
+		Timeout:          time.Duration(fc.Timeout),
+		ReadTimeout:      time.Duration(fc.ReadTimeout),
+		WriteTimeout:     time.Duration(fc.WriteTimeout),
+		MaxAllowedPacket: fc.MaxAllowedPacket,
+		Params:           fc.Params,
+		MaxIdleConn:      fc.MaxIdleConn,
+		MaxOpenConn:      fc.MaxOpenConn,
+		ConnMaxLifetime:  time.Duration(fc.ConnMaxLifetime),
+		ConnMaxIdleTime:  time.Duration(fc.ConnMaxIdleTime),
+		WarmConnections:  fc.WarmConnections,
+		ReplicaPolicy:    ReplicaPolicy(fc.ReplicaPolicy),
+
+		MaxReplicaLag:           time.Duration(fc.MaxReplicaLag),
+		ReplicaLagCheckInterval: time.Duration(fc.ReplicaLagCheckInterval),
+		Consistency:             ConsistencyMode(fc.Consistency),
+		GTIDWaitTimeout:         time.Duration(fc.GTIDWaitTimeout),
+		StickyWindow:            time.Duration(fc.StickyWindow),
+	}
+
+	if fc.PasswordFile != "" {
+		cfg.Credentials = NewFileCredentials("", fc.PasswordFile)
+	}
+
+	for i := range fc.Replicas {
+		rc, err := fc.Replicas[i].config()
+		if err != nil {
+			return Config{}, fmt.Errorf("replica %d: %w", i, err)
+		}
+
+		cfg.Replicas = append(cfg.Replicas, rc)
+	}
+
+	if fc.Loc != "" {
+		loc, err := time.LoadLocation(fc.Loc)
+		if err != nil {
+			return Config{}, err
+		}
+
+		cfg.Loc = loc
+	}
+
+	if fc.Gorm != nil {
+		gormCfg := fc.Gorm.config()
+		cfg.GormConfig = &gormCfg
+	}
+
+	if fc.TLS != nil {
+		cfg.TLS = &TLSConfig{
+			Mode:       TLSMode(fc.TLS.Mode),
+			CAFile:     fc.TLS.CAFile,
+			CertFile:   fc.TLS.CertFile,
+			KeyFile:    fc.TLS.KeyFile,
+			ServerName: fc.TLS.ServerName,
+		}
+	}
+
+	return cfg, nil
+}
+
+// logger builds the GORM logger described by the logger section.
+//
+// Parameters:
+//   - manager: The sklogger.Manager used for logging, or nil to log to standard output.
+//
+// Returns:
+//   - The configured gormlogger.Interface.
+func (fl *FileLogConfig) logger(manager *sklogger.Manager) gormlogger.Interface {
+	level, ok := parseLogLevel(fl.Level)
+	if !ok {
+		level = defaultLogLevel
+	}
+
+	slowThreshold := defaultSlowThreshold
+	if fl.SlowThreshold > 0 {
+		slowThreshold = time.Duration(fl.SlowThreshold)
+	}
+
+	ignoreRecordNotFoundError := defaultIgnoreRecordNotFoundError
+	if fl.IgnoreRecordNotFoundError != nil {
+		ignoreRecordNotFoundError = *fl.IgnoreRecordNotFoundError
+	}
+
+	if manager == nil {
+		return gormlogger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), gormlogger.Config{
+			SlowThreshold:             slowThreshold,
+			LogLevel:                  level,
+			IgnoreRecordNotFoundError: ignoreRecordNotFoundError,
+			Colorful:                  true,
+		})
+	}
+
+	return NewLog(manager,
+		func(l *logger) { l.logLevel = level },
+		WithSlowThreshold(slowThreshold),
+		WithIgnoreRecordNotFoundError(ignoreRecordNotFoundError),
+	)
+}
+
+// parseLogLevel converts the level name of a configuration file into a gormlogger.LogLevel.
+// Unlike WithLevel, "info" is recognized so that a file can enable logging of every query.
+//
+// Parameters:
+//   - level: The level name ("info", "warn", "error", or "silent").
+//
+// Returns:
+//   - The matching gormlogger.LogLevel.
+//   - A boolean indicating whether the level name was recognized.
+func parseLogLevel(level string) (gormlogger.LogLevel, bool) {
+	switch level {
+	case "info":
+		return gormlogger.Info, true
+	case "warn":
+		return gormlogger.Warn, true
+	case "error":
+		return gormlogger.Error, true
+	case "silent":
+		return gormlogger.Silent, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,92 @@
+package mysql
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfigFile(t, "mysql.yaml", `
+max_open_conn: 80
+conn_max_lifetime: 1h
+gorm:
+  prepare_stmt: true
+logger:
+  level: info
+  slow_threshold: 500ms
+connections:
+  - host: 127.0.0.1:33060
+    user: homestead
+    password: secret
+    db_name: mysql_test
+    max_open_conn: 5
+`)
+
+	fc, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := setOption(mustOptions(t, fc)...)
+	if opt.maxOpenConn != 80 || opt.maxIdleConn != defaultMaxIdleConn || opt.connMaxLifetime != time.Hour {
+		t.Errorf("unexpected pool settings: %+v", opt)
+	}
+
+	if !opt.gormConfig.PrepareStmt || opt.gormConfig.Logger == nil {
+		t.Errorf("unexpected gorm config: %+v", opt.gormConfig)
+	}
+
+	cfg := opt.dbConfigs[0].withDefaults(opt)
+	if cfg.MaxOpenConn != 5 || cfg.MaxIdleConn != defaultMaxIdleConn || cfg.ConnMaxLifetime != time.Hour {
+		t.Errorf("per-connection overrides not applied: %+v", cfg)
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	yamlPath := writeConfigFile(t, "mysql.yml", "max_open_con: 80\nconnections:\n  - host: h\n    db_name: d\n")
+	if _, err := LoadConfig(yamlPath); err == nil || !strings.Contains(err.Error(), "max_open_con") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+
+	jsonPath := writeConfigFile(t, "mysql.json", `{"connections":[{"host":"h","db_name":"d","passwd":"x"}]}`)
+	if _, err := LoadConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "passwd") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestLoadConfigRejectsUnknownModes(t *testing.T) {
+	path := writeConfigFile(t, "mysql.yaml", "connections:\n  - host: h\n    db_name: d\n    consistency: GTID\n")
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `unknown consistency mode "GTID"`) {
+		t.Errorf("expected unknown consistency error, got %v", err)
+	}
+
+	path = writeConfigFile(t, "mysql.yaml", "connections:\n  - host: h\n    db_name: d\n    replica_policy: roundrobin\n")
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `"round_robin"`) {
+		t.Errorf("expected unknown replica policy error listing the accepted values, got %v", err)
+	}
+}
+
+func mustOptions(t *testing.T, fc *FileConfig) []Option {
+	t.Helper()
+
+	opts, err := fc.Options(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return opts
+}
@@ -0,0 +1,165 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the health state of a database connection.
+type HealthStatus string
+
+const (
+	// StatusUp means the connection answered its ping.
+	StatusUp HealthStatus = "up"
+	// StatusDown means the connection could not be pinged.
+	StatusDown HealthStatus = "down"
+)
+
+// ConnectionHealth is the result of a health check for a single connection.
+type ConnectionHealth struct {
+	Name    string        `json:"name"`            // Connection name
+	Host    string        `json:"host"`            // Connection host
+	Status  HealthStatus  `json:"status"`          // Health status
+	Latency time.Duration `json:"latency_ns"`      // Ping round trip time
+	Error   string        `json:"error,omitempty"` // Ping error, if any
+	Stats   sql.DBStats   `json:"stats"`           // Connection pool statistics
+}
+
+// HealthReport is the result of a health check for all connections.
+type HealthReport struct {
+	Status      HealthStatus       `json:"status"`      // StatusUp only if every connection is up
+	CheckedAt   time.Time          `json:"checked_at"`  // Time the check started
+	Connections []ConnectionHealth `json:"connections"` // Per-connection results, sorted by name
+}
+
+// WithHealthCheckTimeout returns an Option that sets the timeout of each ping performed by
+// Manager.HealthCheck.
+//
+// Parameters:
+//   - timeout: The maximum time a single ping may take.
+//
+// Returns:
+//   - An Option function that sets the health check timeout when applied.
+//
+// Example:
+//
+//	m, err := NewManager(WithConfigs(cfg), WithHealthCheckTimeout(time.Second))
+func WithHealthCheckTimeout(timeout time.Duration) Option {
+	return func(o *option) {
+		o.healthTimeout = timeout
+	}
+}
+
+// HealthCheck pings every connection concurrently and reports its status, latency and
+// pool statistics.
+//
+// Parameters:
+//   - ctx: The context bounding the whole check. Each ping is additionally bounded by
+//     WithHealthCheckTimeout.
+//
+// Returns:
+//   - The HealthReport with one entry per connection. After Close the report is down
+//     and has no connections.
+//
+// Example:
+//
+//	report := m.HealthCheck(ctx)
+//	if report.Status != StatusUp {
+//	    log.Printf("database unhealthy: %+v", report)
+//	}
+func (m *Manager) HealthCheck(ctx context.Context) HealthReport {
+	report := HealthReport{Status: StatusUp, CheckedAt: time.Now()}
+
+	names, dbs, err := m.snapshot()
+	if err != nil {
+		report.Status = StatusDown
+		return report
+	}
+
+	report.Connections = make([]ConnectionHealth, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			health := ConnectionHealth{Name: name, Host: m.host(name), Status: StatusUp}
+			sqlDB, err := dbs[name].DB()
+			if err == nil {
+				health.Stats = sqlDB.Stats()
+				health.Latency, err = ping(ctx, sqlDB, m.opt.healthTimeout)
+			}
+
+			if err != nil {
+				health.Status = StatusDown
+				health.Error = err.Error()
+			}
+
+			report.Connections[i] = health
+		}()
+	}
+
+	wg.Wait()
+
+	for _, health := range report.Connections {
+		if health.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+// HealthHandler returns an http.Handler that runs HealthCheck and renders the report as
+// JSON. It responds with 200 when every connection is up and 503 otherwise, which makes
+// it suitable for Kubernetes liveness and readiness probes.
+//
+// Returns:
+//   - The http.Handler serving the health report.
+//
+// Example:
+//
+//	http.Handle("/healthz/db", m.HealthHandler())
+func (m *Manager) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := m.HealthCheck(r.Context())
+
+		code := http.StatusOK
+		if report.Status != StatusUp {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// ping pings the pool with a timeout and measures the round trip time.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - sqlDB: The connection pool to ping.
+//   - timeout: The maximum time the ping may take, or 0 for no additional limit.
+//
+// Returns:
+//   - The round trip time.
+//   - An error if the ping fails.
+func ping(ctx context.Context, sqlDB *sql.DB, timeout time.Duration) (time.Duration, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := sqlDB.PingContext(ctx)
+
+	return time.Since(start), err
+}
@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManagerHealthHandler(t *testing.T) {
+	m := newTestManager(t, "orders", "users")
+	defer m.Close(context.Background())
+
+	rec := httptest.NewRecorder()
+	m.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status code = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	var report HealthReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Status != StatusDown || len(report.Connections) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	for _, health := range report.Connections {
+		if health.Status != StatusDown || health.Error == "" || health.Host != "127.0.0.1:1" {
+			t.Errorf("unexpected connection health: %+v", health)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	gomysql "github.com/go-sql-driver/mysql"
+	gormlogger "gorm.io/gorm/logger"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultReplicaLagCheckInterval is the default interval between two replica lag checks.
+	defaultReplicaLagCheckInterval = 5 * time.Second
+	// errParse is the MySQL error number of a syntax error, returned by servers older
+	// than 8.0.22 for SHOW REPLICA STATUS.
+	errParse = 1064
+)
+
+// errNotReplicating is returned when a replica reports no replication status.
+var errNotReplicating = errors.New("replication is not configured")
+
+// lagChecker periodically measures the replication lag of every replica and ejects the
+// replicas lagging further behind than the threshold from routing.
+type lagChecker struct {
+	replicas []*replica
+	maxLag   time.Duration
+	interval time.Duration
+	log      gormlogger.Interface
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startLagChecker starts checking the replication lag of the replicas in the background.
+//
+// Parameters:
+//   - replicas: The replicas to check.
+//   - maxLag: The lag beyond which a replica is ejected.
+//   - interval: The time between two checks, defaults to defaultReplicaLagCheckInterval.
+//   - log: The logger receiving the lag measurements and ejection events.
+//
+// Returns:
+//   - The running lag checker, or nil if maxLag is not greater than 0.
+func startLagChecker(replicas []*replica, maxLag, interval time.Duration, log gormlogger.Interface) *lagChecker {
+	if maxLag <= 0 || len(replicas) == 0 {
+		return nil
+	}
+
+	if interval <= 0 {
+		interval = defaultReplicaLagCheckInterval
+	}
+
+	lc := &lagChecker{
+		replicas: replicas,
+		maxLag:   maxLag,
+		interval: interval,
+		log:      log,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go lc.run()
+
+	return lc
+}
+
+// run checks the replicas at every interval until the lag checker is stopped.
+func (lc *lagChecker) run() {
+	defer close(lc.done)
+
+	ticker := time.NewTicker(lc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lc.stop:
+			return
+		case <-ticker.C:
+			lc.check()
+		}
+	}
+}
+
+// check measures the lag of every replica once and updates its routing state.
+func (lc *lagChecker) check() {
+	for _, rep := range lc.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), lc.interval)
+		lag, err := rep.lag(ctx)
+		cancel()
+
+		lc.observe(rep, lag, err)
+	}
+}
+
+// observe ejects a replica whose lag exceeds the threshold or cannot be measured, and
+// re-admits it once it has caught up.
+//
+// Parameters:
+//   - rep: The replica that was checked.
+//   - lag: The measured replication lag.
+//   - err: The error that prevented measuring the lag, if any.
+func (lc *lagChecker) observe(rep *replica, lag time.Duration, err error) {
+	ctx := context.Background()
+	if err == nil {
+		lc.log.Info(ctx, "replica %q (%s) lag is %s", rep.name, rep.host, lag)
+	}
+
+	healthy := err == nil && lag <= lc.maxLag
+	wasEjected := rep.ejected.Load()
+
+	switch {
+	case !healthy && !wasEjected:
+		rep.ejected.Store(true)
+		if err != nil {
+			lc.log.Warn(ctx, "replica %q (%s) ejected: %v", rep.name, rep.host, err)
+		} else {
+			lc.log.Warn(ctx, "replica %q (%s) ejected: lag %s exceeds %s", rep.name, rep.host, lag, lc.maxLag)
+		}
+	case healthy && wasEjected:
+		rep.ejected.Store(false)
+		lc.log.Info(ctx, "replica %q (%s) re-admitted: lag %s", rep.name, rep.host, lag)
+	}
+}
+
+// Stop stops the lag checker and waits for the running check to finish. It is safe to
+// call Stop on a nil lag checker and to call it several times.
+func (lc *lagChecker) Stop() {
+	if lc == nil {
+		return
+	}
+
+	lc.stopOnce.Do(func() {
+		close(lc.stop)
+	})
+	<-lc.done
+}
+
+// lag queries the replication lag of the replica. SHOW REPLICA STATUS is tried first and
+// SHOW SLAVE STATUS is used on servers that do not support it.
+//
+// Parameters:
+//   - ctx: The context bounding the query.
+//
+// Returns:
+//   - The number of seconds the replica is behind its source.
+//   - An error if the status cannot be read, replication is not configured, or the
+//     replication SQL thread is not running.
+func (rep *replica) lag(ctx context.Context) (time.Duration, error) {
+	if !rep.legacyStatus.Load() {
+		lag, err := replicationLag(ctx, rep.sqlDB, "SHOW REPLICA STATUS")
+
+		var myErr *gomysql.MySQLError
+		if !errors.As(err, &myErr) || myErr.Number != errParse {
+			return lag, err
+		}
+
+		rep.legacyStatus.Store(true)
+	}
+
+	return replicationLag(ctx, rep.sqlDB, "SHOW SLAVE STATUS")
+}
+
+// replicationLag runs a replication status statement and extracts the lag from its result.
+//
+// Parameters:
+//   - ctx: The context bounding the query.
+//   - db: The replica pool.
+//   - query: The replication status statement.
+//
+// Returns:
+//   - The replication lag.
+//   - An error if the statement fails or its result holds no lag.
+func replicationLag(ctx context.Context, db *sql.DB, query string) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return 0, err
+		}
+
+		return 0, errNotReplicating
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	if err = rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	return secondsBehind(columns, values)
+}
+
+// secondsBehind extracts the replication lag from a replication status row.
+//
+// Parameters:
+//   - columns: The column names of the row.
+//   - values: The values of the row.
+//
+// Returns:
+//   - The value of Seconds_Behind_Source, or Seconds_Behind_Master on older servers.
+//   - An error if the column is missing, NULL or not a number.
+func secondsBehind(columns []string, values []sql.NullString) (time.Duration, error) {
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+
+		if !values[i].Valid {
+			return 0, errors.New("replication is not running")
+		}
+
+		seconds, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", column, values[i].String, err)
+		}
+
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return 0, errors.New("replication status has no Seconds_Behind_Source column")
+}
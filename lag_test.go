@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+func TestSecondsBehind(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		values  []sql.NullString
+		want    time.Duration
+		wantErr bool
+	}{
+		{"source", []string{"Replica_IO_State", "Seconds_Behind_Source"}, []sql.NullString{{}, {String: "12", Valid: true}}, 12 * time.Second, false},
+		{"master", []string{"Seconds_Behind_Master"}, []sql.NullString{{String: "0", Valid: true}}, 0, false},
+		{"not running", []string{"Seconds_Behind_Source"}, []sql.NullString{{}}, 0, true},
+		{"missing", []string{"Replica_IO_State"}, []sql.NullString{{String: "", Valid: true}}, 0, true},
+		{"invalid", []string{"Seconds_Behind_Source"}, []sql.NullString{{String: "soon", Valid: true}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := secondsBehind(tt.columns, tt.values)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: secondsBehind() = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLagCheckerObserve(t *testing.T) {
+	rep := &replica{name: "shop-replica-1", host: "127.0.0.2:1"}
+	lc := &lagChecker{maxLag: 10 * time.Second, log: gormlogger.Discard}
+
+	steps := []struct {
+		lag     time.Duration
+		err     error
+		ejected bool
+	}{
+		{5 * time.Second, nil, false},
+		{30 * time.Second, nil, true},
+		{20 * time.Second, nil, true},
+		{10 * time.Second, nil, false},
+		{0, errors.New("connection refused"), true},
+		{0, nil, false},
+	}
+
+	for i, step := range steps {
+		lc.observe(rep, step.lag, step.err)
+		if got := rep.ejected.Load(); got != step.ejected {
+			t.Errorf("step %d: ejected = %v, want %v", i, got, step.ejected)
+		}
+	}
+}
+
+func TestResolverLagCheck(t *testing.T) {
+	cfg := Config{
+		User:                    "user",
+		Host:                    "127.0.0.1:1",
+		DBName:                  "shop",
+		Timeout:                 time.Second,
+		Replicas:                []Config{{Host: "127.0.0.2:1"}},
+		MaxReplicaLag:           time.Second,
+		ReplicaLagCheckInterval: 10 * time.Millisecond,
+		GormConfig:              &gorm.Config{Logger: gormlogger.Discard},
+	}
+
+	db, err := New(WithConfigs(cfg), WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := db.Config.Plugins[resolverPluginName].(*resolver)
+	if r.checker == nil {
+		t.Fatal("lag checker was not started")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !r.replicas[0].ejected.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if !r.replicas[0].ejected.Load() {
+		t.Error("unreachable replica was not ejected")
+	}
+
+	if err = closeDB(db); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing again must not stop the lag checker twice
+	_ = closeDB(db)
+}
@@ -0,0 +1,286 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrUnknownConnection is returned by Manager.Get when no connection has the requested name.
+	ErrUnknownConnection = errors.New("unknown database connection")
+	// ErrManagerClosed is returned by Manager methods after Close has been called.
+	ErrManagerClosed = errors.New("database connection manager is closed")
+)
+
+// Manager owns a set of named database connections and provides lookup and shutdown
+// in a single place.
+type Manager struct {
+	mu     sync.RWMutex
+	opt    *option
+	dbs    map[string]*gorm.DB
+	cfgs   map[string]Config
+	mon    *monitor
+	closed bool
+	done   chan struct{} // Closed by Close
+
+	reloadMu sync.Mutex     // Serializes Reload calls
+	draining sync.WaitGroup // Pools replaced by Reload that are not closed yet
+}
+
+// NewManager initializes the configured database connections and returns a Manager
+// that owns them. It accepts the same options as NewMulti.
+//
+// Parameters:
+//   - opts: A variadic list of Option functions to configure the database connections.
+//
+// Returns:
+//   - A pointer to the Manager owning the connections.
+//   - An error if the initialization fails.
+//
+// Example:
+//
+//	m, err := NewManager(WithConfigs(cfg1, cfg2))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer m.Close(context.Background())
+//
+//	orders := m.MustGet("orders")
+func NewManager(opts ...Option) (*Manager, error) {
+	return NewManagerContext(context.Background(), opts...)
+}
+
+// NewManagerContext is like NewManager but stops connecting, including retries, when ctx is done.
+//
+// Parameters:
+//   - ctx: The context bounding the initialization.
+//   - opts: A variadic list of Option functions to configure the database connections.
+//
+// Returns:
+//   - A pointer to the Manager owning the connections.
+//   - An error if the initialization fails or ctx is done first.
+func NewManagerContext(ctx context.Context, opts ...Option) (*Manager, error) {
+	opt := setOption(opts...)
+
+	dbs, err := newMulti(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs := configsByKey(opt.dbConfigs)
+	m := &Manager{opt: opt, dbs: dbs, cfgs: cfgs, done: make(chan struct{})}
+	if err = registerStats(opt, m.poolStats); err != nil {
+		_ = closeAll(dbs)
+		return nil, err
+	}
+
+	m.mon = startMonitor(opt, dbs, cfgs)
+
+	return m, nil
+}
+
+// Get returns the connection with the given name.
+//
+// Parameters:
+//   - name: The connection name, i.e. Config.Name or the database name.
+//
+// Returns:
+//   - A pointer to the gorm.DB instance of the connection.
+//   - ErrUnknownConnection if no connection has the name, or ErrManagerClosed after Close.
+func (m *Manager) Get(name string) (*gorm.DB, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+
+	db, ok := m.dbs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownConnection, name)
+	}
+
+	return db, nil
+}
+
+// MustGet is like Get but panics if the connection cannot be returned.
+//
+// Parameters:
+//   - name: The connection name, i.e. Config.Name or the database name.
+//
+// Returns:
+//   - A pointer to the gorm.DB instance of the connection.
+func (m *Manager) MustGet(name string) *gorm.DB {
+	db, err := m.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+// Names returns the names of all connections in sorted order.
+//
+// Returns:
+//   - A sorted slice of connection names.
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.dbs))
+	for name := range m.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Each calls fn for every connection in name order and stops at the first error.
+//
+// Parameters:
+//   - fn: The function to call with each connection name and gorm.DB instance.
+//
+// Returns:
+//   - The first error returned by fn, or ErrManagerClosed after Close.
+//
+// Example:
+//
+//	err := m.Each(func(name string, db *gorm.DB) error {
+//	    return db.AutoMigrate(&Product{})
+//	})
+func (m *Manager) Each(fn func(name string, db *gorm.DB) error) error {
+	names, dbs, err := m.snapshot()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err = fn(name, dbs[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// snapshot returns a consistent copy of the connections so they can be used without
+// holding the lock.
+//
+// Returns:
+//   - The sorted connection names.
+//   - A copy of the connections keyed by name.
+//   - ErrManagerClosed after Close.
+func (m *Manager) snapshot() ([]string, map[string]*gorm.DB, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return nil, nil, ErrManagerClosed
+	}
+
+	names := make([]string, 0, len(m.dbs))
+	dbs := make(map[string]*gorm.DB, len(m.dbs))
+	for name, db := range m.dbs {
+		names = append(names, name)
+		dbs[name] = db
+	}
+	sort.Strings(names)
+
+	return names, dbs, nil
+}
+
+// poolStats returns the statistics reported to the stats registry.
+//
+// Returns:
+//   - The statistics of the current pools and of their replicas, or nil after Close.
+func (m *Manager) poolStats() []PoolStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return nil
+	}
+
+	return collectPoolStats(m.dbs, m.cfgs)
+}
+
+// host returns the host of the named connection for reports and log messages.
+//
+// Parameters:
+//   - name: The connection name.
+//
+// Returns:
+//   - The host of the connection, or an empty string if it is unknown.
+func (m *Manager) host(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cfg, ok := m.cfgs[name]
+	if !ok {
+		return ""
+	}
+
+	return cfg.host()
+}
+
+// Close stops the background monitor and closes every underlying sql.DB, including pools
+// replaced by Reload that are still draining. Queries that are already running are allowed to
+// finish; Close returns early with the context error if ctx is done first, while the
+// pools keep closing in the background.
+//
+// Parameters:
+//   - ctx: The context bounding how long Close waits.
+//
+// Returns:
+//   - The joined errors of every pool that failed to close, ErrManagerClosed if Close
+//     was already called, or the context error.
+func (m *Manager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrManagerClosed
+	}
+
+	m.closed = true
+	close(m.done)
+	dbs, mon := m.dbs, m.mon
+	m.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		mon.Stop()
+		err := closeAll(dbs)
+		m.draining.Wait()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeAll closes the pools of all given connections.
+//
+// Parameters:
+//   - dbs: The connections to close, keyed by name.
+//
+// Returns:
+//   - The joined errors of every pool that failed to close, each naming its connection.
+func closeAll(dbs map[string]*gorm.DB) error {
+	var errs []error
+	for name, db := range dbs {
+		if err := closeDB(db); err != nil {
+			errs = append(errs, fmt.Errorf("close %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+)
+
+// nopConnector is a driver.Connector that is never dialed.
+type nopConnector struct{}
+
+func (nopConnector) Connect(context.Context) (driver.Conn, error) { return nil, driver.ErrBadConn }
+func (nopConnector) Driver() driver.Driver                        { return nil }
+
+// poolClosed reports whether a pool has been closed, by comparing its Ping error with the
+// one database/sql returns for a pool that is known to be closed.
+func poolClosed(db *sql.DB) bool {
+	closed := sql.OpenDB(nopConnector{})
+	_ = closed.Close()
+
+	return errors.Is(db.Ping(), closed.Ping())
+}
+
+// newTestManager returns a Manager whose connections are never dialed.
+func newTestManager(t *testing.T, names ...string) *Manager {
+	t.Helper()
+
+	cfgs := make([]Config, 0, len(names))
+	for _, name := range names {
+		cfgs = append(cfgs, Config{User: "user", Host: "127.0.0.1:1", DBName: name})
+	}
+
+	m, err := NewManager(WithConfigs(cfgs...), WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestManager(t *testing.T) {
+	m := newTestManager(t, "users", "orders")
+
+	if got := m.Names(); !reflect.DeepEqual(got, []string{"orders", "users"}) {
+		t.Errorf("Names() = %v", got)
+	}
+
+	if _, err := m.Get("orders"); err != nil {
+		t.Errorf("Get(orders) error = %v", err)
+	}
+
+	if _, err := m.Get("missing"); !errors.Is(err, ErrUnknownConnection) {
+		t.Errorf("Get(missing) error = %v, want ErrUnknownConnection", err)
+	}
+
+	var visited []string
+	err := m.Each(func(name string, db *gorm.DB) error {
+		visited = append(visited, name)
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(visited, []string{"orders", "users"}) {
+		t.Errorf("Each visited %v, error = %v", visited, err)
+	}
+
+	if err = m.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = m.Get("orders"); !errors.Is(err, ErrManagerClosed) {
+		t.Errorf("Get after Close error = %v, want ErrManagerClosed", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustGet after Close did not panic")
+			}
+		}()
+		m.MustGet("orders")
+	}()
+}
@@ -0,0 +1,149 @@
+package mysql
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	// metricsPluginName is the name under which the metrics plugin is registered.
+	metricsPluginName = "mysql:metrics"
+	// metricsStartKey is the statement setting holding the start time of an operation.
+	metricsStartKey = "mysql:metrics:start"
+)
+
+// QueryMetric describes a finished GORM operation.
+type QueryMetric struct {
+	Connection string        // Connection name, see Config.Name
+	Table      string        // Table of the statement, empty for raw SQL without a model
+	Operation  string        // One of create, query, update, delete, row and raw
+	Duration   time.Duration // Time spent in the operation, including its callbacks
+	Err        error         // Error of the operation; gorm.ErrRecordNotFound is not reported
+}
+
+// MetricsSink receives the metric of every GORM operation recorded by the metrics plugin.
+// Implementations must be safe for concurrent use and should not block.
+type MetricsSink interface {
+	ObserveQuery(m QueryMetric)
+}
+
+// WithMetrics returns an Option that registers the metrics plugin on every connection,
+// reporting the latency and error of every create, query, update, delete, row and raw
+// operation to the sink. Reads routed to replicas are reported under the primary connection,
+// and the pools of a TenantResolver under the name of its template, or "tenants" when the
+// template has no name, so that the number of series does not grow with the tenants.
+//
+// Parameters:
+//   - sink: The sink receiving the metrics, e.g. a mysqlprom.PrometheusSink.
+//
+// Returns:
+//   - An Option function that enables the metrics plugin when applied.
+//
+// Example:
+//
+//	sink, err := mysqlprom.NewPrometheusSink(prometheus.DefaultRegisterer, nil)
+//	db, err := New(WithConfigs(cfg), WithMetrics(sink))
+func WithMetrics(sink MetricsSink) Option {
+	return func(o *option) {
+		o.metrics = sink
+	}
+}
+
+// metricsPlugin is a GORM plugin that times every operation through before and after
+// callbacks and reports it to a MetricsSink.
+type metricsPlugin struct {
+	connection string
+	sink       MetricsSink
+}
+
+// NewMetricsPlugin returns a GORM plugin reporting the latency and error of every operation
+// of a gorm.DB to the sink. WithMetrics registers it on the connections opened by this
+// package; use NewMetricsPlugin for connections opened otherwise.
+//
+// Parameters:
+//   - connection: The connection name reported with every metric.
+//   - sink: The sink receiving the metrics.
+//
+// Returns:
+//   - The plugin, to be registered with gorm.DB.Use.
+//
+// Example:
+//
+//	err := db.Use(NewMetricsPlugin("orders", sink))
+func NewMetricsPlugin(connection string, sink MetricsSink) gorm.Plugin {
+	return &metricsPlugin{connection: connection, sink: sink}
+}
+
+// Name returns the plugin name.
+func (p *metricsPlugin) Name() string {
+	return metricsPluginName
+}
+
+// Initialize registers the timing callbacks around every operation.
+//
+// Parameters:
+//   - db: The gorm.DB to instrument.
+//
+// Returns:
+//   - An error if a callback cannot be registered.
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	return errors.Join(
+		cb.Create().Before("*").Register(metricsPluginName+":before_create", p.before),
+		cb.Create().After("*").Register(metricsPluginName+":after_create", p.after("create")),
+		cb.Query().Before("*").Register(metricsPluginName+":before_query", p.before),
+		cb.Query().After("*").Register(metricsPluginName+":after_query", p.after("query")),
+		cb.Update().Before("*").Register(metricsPluginName+":before_update", p.before),
+		cb.Update().After("*").Register(metricsPluginName+":after_update", p.after("update")),
+		cb.Delete().Before("*").Register(metricsPluginName+":before_delete", p.before),
+		cb.Delete().After("*").Register(metricsPluginName+":after_delete", p.after("delete")),
+		cb.Row().Before("*").Register(metricsPluginName+":before_row", p.before),
+		cb.Row().After("*").Register(metricsPluginName+":after_row", p.after("row")),
+		cb.Raw().Before("*").Register(metricsPluginName+":before_raw", p.before),
+		cb.Raw().After("*").Register(metricsPluginName+":after_raw", p.after("raw")),
+	)
+}
+
+// before records the start time of an operation.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+func (p *metricsPlugin) before(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+// after returns the callback reporting a finished operation to the sink.
+//
+// Parameters:
+//   - operation: The operation name reported with the metric.
+//
+// Returns:
+//   - The callback.
+func (p *metricsPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+
+		p.sink.ObserveQuery(QueryMetric{
+			Connection: p.connection,
+			Table:      db.Statement.Table,
+			Operation:  operation,
+			Duration:   time.Since(start),
+			Err:        err,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"sync"
+	"testing"
+)
+
+// recordingSink is a MetricsSink keeping every metric it receives.
+type recordingSink struct {
+	mu      sync.Mutex
+	metrics []QueryMetric
+}
+
+func (s *recordingSink) ObserveQuery(m QueryMetric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.metrics = append(s.metrics, m)
+}
+
+type metricsOrder struct {
+	ID    uint
+	Total int
+}
+
+func TestMetricsPlugin(t *testing.T) {
+	sink := &recordingSink{}
+	m, err := NewManager(WithConfigs(Config{User: "user", Host: "127.0.0.1:1", DBName: "orders"}),
+		WithLazyConnect(), WithMetrics(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = m.Close(context.Background()) }()
+
+	db := m.MustGet("orders")
+	dry := db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true})
+
+	dry.Create(&metricsOrder{Total: 1})
+	dry.Model(&metricsOrder{}).Where("id = ?", 1).Update("total", 2)
+	dry.Delete(&metricsOrder{}, 1)
+	dry.Exec("SELECT 1")
+
+	var orders []metricsOrder
+	if err = db.Find(&orders).Error; err == nil {
+		t.Fatal("expected a connection error")
+	}
+
+	want := []QueryMetric{
+		{Connection: "orders", Table: "metrics_orders", Operation: "create"},
+		{Connection: "orders", Table: "metrics_orders", Operation: "update"},
+		{Connection: "orders", Table: "metrics_orders", Operation: "delete"},
+		{Connection: "orders", Table: "", Operation: "raw"},
+		{Connection: "orders", Table: "metrics_orders", Operation: "query"},
+	}
+
+	if len(sink.metrics) != len(want) {
+		t.Fatalf("recorded %d metrics, want %d: %+v", len(sink.metrics), len(want), sink.metrics)
+	}
+
+	for i, got := range sink.metrics {
+		if got.Connection != want[i].Connection || got.Table != want[i].Table || got.Operation != want[i].Operation {
+			t.Errorf("metric %d = %+v, want %+v", i, got, want[i])
+		}
+
+		if failed := got.Err != nil; failed != (i == len(want)-1) {
+			t.Errorf("metric %d error = %v", i, got.Err)
+		}
+	}
+}
+
+func TestMetricsPluginTargets(t *testing.T) {
+	cfg := Config{User: "user", Host: "127.0.0.1:1", DBName: "orders", Replicas: []Config{{Host: "127.0.0.2:1"}}}
+	db, err := New(WithConfigs(cfg), WithLazyConnect(), WithMetrics(&recordingSink{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = closeDB(db) }()
+
+	r := db.Config.Plugins[resolverPluginName].(*resolver)
+	if _, ok := r.replicas[0].db.Config.Plugins[metricsPluginName]; ok {
+		t.Error("metrics plugin was registered on a replica")
+	}
+
+	tenants, err := NewTenantResolver(Config{User: "app", Host: "127.0.0.1:1"}, WithLazyConnect(), WithMetrics(&recordingSink{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tenants.Close(context.Background()) }()
+
+	acme, release, err := tenants.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	if p, ok := acme.Config.Plugins[metricsPluginName].(*metricsPlugin); !ok || p.connection != defaultTenantMetricsName {
+		t.Errorf("tenant pool metrics plugin = %+v, want connection %q", p, defaultTenantMetricsName)
+	}
+}
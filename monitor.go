@@ -0,0 +1,348 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatusDegraded means the connection answered its ping, but slower than the degraded
+// latency threshold or while its pool was saturated.
+const StatusDegraded HealthStatus = "degraded"
+
+// monitorPluginName is the name under which a monitor is attached to the connections it
+// watches, so that closing a connection with Close stops watching it.
+const monitorPluginName = "mysql:monitor"
+
+// StateChangeFunc is called by the connection monitor when a connection changes state.
+type StateChangeFunc func(name string, old, new HealthStatus)
+
+// monitorOption holds the settings of the background connection monitor.
+type monitorOption struct {
+	interval        time.Duration   // Time between two checks, the monitor is disabled when 0
+	degradedLatency time.Duration   // Ping latency above which a connection is degraded
+	onChange        StateChangeFunc // Callback invoked on every state transition
+}
+
+// WithMonitor returns an Option that starts a background monitor pinging every connection
+// at the given interval. State transitions between up, degraded and down are logged through
+// the configured GORM logger and reported to the WithOnStateChange callback.
+//
+// Monitors started by NewManager stop when the Manager is closed. Monitors started by New
+// or NewMulti stop once all of their connections have been closed with Close.
+//
+// Parameters:
+//   - interval: The time between two checks.
+//
+// Returns:
+//   - An Option function that enables the monitor when applied.
+//
+// Example:
+//
+//	m, err := NewManager(WithConfigs(cfg), WithMonitor(10*time.Second))
+func WithMonitor(interval time.Duration) Option {
+	return func(o *option) {
+		o.monitor.interval = interval
+	}
+}
+
+// WithDegradedLatency returns an Option that sets the ping latency above which the monitor
+// reports a connection as degraded.
+//
+// Parameters:
+//   - latency: The latency threshold, or 0 to only report saturated pools as degraded.
+//
+// Returns:
+//   - An Option function that sets the degraded latency when applied.
+//
+// Example:
+//
+//	m, err := NewManager(WithConfigs(cfg), WithMonitor(10*time.Second), WithDegradedLatency(200*time.Millisecond))
+func WithDegradedLatency(latency time.Duration) Option {
+	return func(o *option) {
+		o.monitor.degradedLatency = latency
+	}
+}
+
+// WithOnStateChange returns an Option that sets the callback invoked by the monitor when a
+// connection changes state. The callback is called from the monitor goroutine and should
+// not block.
+//
+// Parameters:
+//   - fn: The callback receiving the connection name and its old and new state.
+//
+// Returns:
+//   - An Option function that sets the callback when applied.
+//
+// Example:
+//
+//	m, err := NewManager(
+//	    WithConfigs(cfg),
+//	    WithMonitor(10*time.Second),
+//	    WithOnStateChange(func(name string, old, new HealthStatus) {
+//	        alerts.Notify(name, string(new))
+//	    }),
+//	)
+func WithOnStateChange(fn StateChangeFunc) Option {
+	return func(o *option) {
+		o.monitor.onChange = fn
+	}
+}
+
+// monitorTarget is a connection watched by the monitor.
+type monitorTarget struct {
+	name   string
+	host   string
+	db     *sql.DB
+	log    gormlogger.Interface
+	state  HealthStatus
+	closed bool
+}
+
+// monitor periodically pings a set of connections and reports state transitions.
+type monitor struct {
+	opt      monitorOption
+	timeout  time.Duration
+	targets  []*monitorTarget
+	mu       sync.Mutex // Guards the closed flag of the targets
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// monitorPlugin attaches a monitor to one of the connections it watches. It owns no
+// callbacks; closing the connection with Close stops watching it.
+type monitorPlugin struct {
+	mon  *monitor
+	name string
+}
+
+// startMonitor starts a monitor for the given connections if the monitor is enabled.
+//
+// Parameters:
+//   - opt: A pointer to the option struct holding the monitor settings.
+//   - dbs: The connections to watch, keyed by name.
+//   - cfgs: The configurations of the connections, keyed by name.
+//
+// Returns:
+//   - A pointer to the running monitor, or nil if the monitor is disabled.
+func startMonitor(opt *option, dbs map[string]*gorm.DB, cfgs map[string]Config) *monitor {
+	if opt.monitor.interval <= 0 || len(dbs) == 0 {
+		return nil
+	}
+
+	mon := &monitor{
+		opt:     opt.monitor,
+		timeout: opt.healthTimeout,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	for name, db := range dbs {
+		sqlDB, err := db.DB()
+		if err != nil {
+			continue
+		}
+
+		cfg := cfgs[name]
+		resolved := cfg.withDefaults(opt)
+		mon.targets = append(mon.targets, &monitorTarget{
+			name:  name,
+			host:  cfg.host(),
+			db:    sqlDB,
+			log:   resolved.logger(),
+			state: StatusUp,
+		})
+	}
+	sort.Slice(mon.targets, func(i, j int) bool { return mon.targets[i].name < mon.targets[j].name })
+
+	go mon.run()
+
+	return mon
+}
+
+// attach registers the monitor on every connection it watches, so that the monitor stops
+// once all of them have been closed with Close. Used for connections that, unlike those of
+// a Manager, have no owner stopping the monitor.
+//
+// Parameters:
+//   - dbs: The watched connections, keyed by name.
+//
+// Returns:
+//   - An error if the plugin cannot be registered.
+func (m *monitor) attach(dbs map[string]*gorm.DB) error {
+	if m == nil {
+		return nil
+	}
+
+	var errs []error
+	for name, db := range dbs {
+		errs = append(errs, db.Use(&monitorPlugin{mon: m, name: name}))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Name returns the plugin name.
+func (p *monitorPlugin) Name() string {
+	return monitorPluginName
+}
+
+// Initialize does nothing; the plugin only ties the monitor to the connection's lifetime.
+func (p *monitorPlugin) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// close stops watching the connection, and stops the monitor once no connection is left.
+//
+// Returns:
+//   - Always nil.
+func (p *monitorPlugin) close() error {
+	p.mon.mu.Lock()
+	open := false
+	for _, t := range p.mon.targets {
+		if t.name == p.name {
+			t.closed = true
+		}
+		open = open || !t.closed
+	}
+	p.mon.mu.Unlock()
+
+	if !open {
+		p.mon.Stop()
+	}
+
+	return nil
+}
+
+// configsByKey indexes configurations by their connection name.
+//
+// Parameters:
+//   - cfgs: The configurations to index.
+//
+// Returns:
+//   - The configurations keyed by Config.key.
+func configsByKey(cfgs []Config) map[string]Config {
+	indexed := make(map[string]Config, len(cfgs))
+	for _, cfg := range cfgs {
+		indexed[cfg.key()] = cfg
+	}
+
+	return indexed
+}
+
+// run checks all targets at every interval until the monitor is stopped or every pool
+// has been closed.
+func (m *monitor) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.opt.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			if !m.check() {
+				return
+			}
+		}
+	}
+}
+
+// check pings every open target once and reports state transitions.
+//
+// Returns:
+//   - false if every target has been closed, true otherwise.
+func (m *monitor) check() bool {
+	open := false
+	for _, t := range m.targets {
+		m.mu.Lock()
+		closed := t.closed
+		m.mu.Unlock()
+
+		if closed {
+			continue
+		}
+
+		open = true
+		latency, err := ping(context.Background(), t.db, m.timeout)
+		m.transition(t, m.status(t.db, latency, err), err)
+	}
+
+	return open
+}
+
+// status derives the state of a connection from its ping result and pool statistics.
+//
+// Parameters:
+//   - db: The connection pool.
+//   - latency: The ping round trip time.
+//   - err: The ping error, if any.
+//
+// Returns:
+//   - The state of the connection.
+func (m *monitor) status(db *sql.DB, latency time.Duration, err error) HealthStatus {
+	if err != nil {
+		return StatusDown
+	}
+
+	if m.opt.degradedLatency > 0 && latency > m.opt.degradedLatency {
+		return StatusDegraded
+	}
+
+	stats := db.Stats()
+	if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		return StatusDegraded
+	}
+
+	return StatusUp
+}
+
+// transition records the new state of a target and reports it if it changed.
+//
+// Parameters:
+//   - t: The target that was checked.
+//   - state: The state observed by the check.
+//   - err: The ping error, if any.
+func (m *monitor) transition(t *monitorTarget, state HealthStatus, err error) {
+	old := t.state
+	if old == state {
+		return
+	}
+
+	t.state = state
+
+	ctx := context.Background()
+	switch state {
+	case StatusDown:
+		t.log.Error(ctx, "database %q (%s) is %s (was %s): %v", t.name, t.host, state, old, err)
+	case StatusDegraded:
+		t.log.Warn(ctx, "database %q (%s) is %s (was %s)", t.name, t.host, state, old)
+	default:
+		t.log.Info(ctx, "database %q (%s) is %s (was %s)", t.name, t.host, state, old)
+	}
+
+	if m.opt.onChange != nil {
+		m.opt.onChange(t.name, old, state)
+	}
+}
+
+// Stop stops the monitor and waits for the running check to finish. It is safe to call
+// Stop on a nil monitor and to call it several times.
+func (m *monitor) Stop() {
+	if m == nil {
+		return
+	}
+
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+}
@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManagerMonitor(t *testing.T) {
+	type change struct {
+		name     string
+		old, new HealthStatus
+	}
+
+	var (
+		mu      sync.Mutex
+		changes []change
+	)
+
+	cfg := Config{User: "user", Host: "127.0.0.1:1", DBName: "orders", Timeout: time.Second}
+	m, err := NewManager(
+		WithConfigs(cfg),
+		WithLazyConnect(),
+		WithMonitor(10*time.Millisecond),
+		WithOnStateChange(func(name string, old, new HealthStatus) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, change{name, old, new})
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(changes)
+		mu.Unlock()
+
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err = m.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(changes) != 1 || changes[0] != (change{"orders", StatusUp, StatusDown}) {
+		t.Errorf("unexpected state changes: %+v", changes)
+	}
+}
+
+func TestMonitorStopsOnClose(t *testing.T) {
+	dbs, err := NewMulti(
+		WithConfigs(Config{User: "user", Host: "127.0.0.1:1", DBName: "orders"}, Config{User: "user", Host: "127.0.0.1:1", DBName: "users"}),
+		WithLazyConnect(),
+		WithMonitor(time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mon := dbs["orders"].Config.Plugins[monitorPluginName].(*monitorPlugin).mon
+
+	if err = Close(dbs["orders"]); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-mon.done:
+		t.Fatal("monitor stopped while a connection was still open")
+	default:
+	}
+
+	if err = Close(dbs["users"]); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-mon.done:
+	case <-time.After(time.Second):
+		t.Error("monitor did not stop after every connection was closed")
+	}
+}
@@ -4,10 +4,15 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	gomysql "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"sync"
 	"time"
 )
 
@@ -18,14 +23,50 @@ const (
 	defaultMaxOpenConn = 50
 	// defaultConnMaxLifetime is the default maximum amount of time a connection may be reused.
 	defaultConnMaxLifetime = 3 * time.Hour
+	// defaultHealthTimeout is the default timeout of each ping performed by a health check.
+	defaultHealthTimeout = 2 * time.Second
+	// defaultConnectConcurrency is the default number of connections NewMulti opens concurrently.
+	defaultConnectConcurrency = 4
 )
 
 // Config represents the configuration for a MySQL database connection.
 type Config struct {
-	User     string // Database user
-	Password string // Database password
-	Host     string // Database host
-	DBName   string // Database name
+	Name     string   // Connection name used as the NewMulti map key, defaults to DBName
+	User     string   // Database user
+	Password string   // Database password
+	Network  string   // Network type, "tcp" (default) or "unix"
+	Host     string   // Database host, optionally including the port, or the socket path for "unix"
+	Hosts    []string // Candidate hosts of a primary/standby pair; the writable one is used and Host is ignored
+	Port     int      // Database port, used when Host does not already contain one
+	DBName   string   // Database name
+	DSN      string   // Raw DSN used verbatim when set; all other connection fields are ignored
+
+	Credentials CredentialProvider // Provider of the user and password of every new connection, overrides User and Password when set
+
+	Charset          string            // Connection character set, defaults to utf8mb4
+	Collation        string            // Connection collation, defaults to the driver default
+	Loc              *time.Location    // Location used for time.Time values, defaults to time.Local
+	Timeout          time.Duration     // Dial timeout
+	ReadTimeout      time.Duration     // I/O read timeout
+	WriteTimeout     time.Duration     // I/O write timeout
+	MaxAllowedPacket int               // Max packet size allowed, defaults to the driver default
+	Params           map[string]string // Additional driver parameters appended to the DSN
+	TLS              *TLSConfig        // TLS settings, overrides the default set by WithTLS
+
+	MaxIdleConn     int           // Maximum idle connections, overrides WithMaxIdleConn when greater than 0
+	MaxOpenConn     int           // Maximum open connections, overrides WithMaxOpenConn when greater than 0
+	ConnMaxLifetime time.Duration // Maximum connection lifetime, overrides WithConnMaxLifetime when greater than 0
+	ConnMaxIdleTime time.Duration // Maximum connection idle time, overrides WithConnMaxIdleTime when greater than 0
+	WarmConnections int           // Connections opened during startup, overrides WithWarmConnections when greater than 0
+	GormConfig      *gorm.Config  // GORM configuration, overrides WithGormConfig when set; inherits its Logger when nil
+
+	Replicas                []Config        // Read replicas; unset fields are inherited from this configuration
+	ReplicaPolicy           ReplicaPolicy   // Replica selection policy, defaults to ReplicaPolicyRandom
+	MaxReplicaLag           time.Duration   // Replicas lagging further behind are ejected from routing, 0 disables the lag check
+	ReplicaLagCheckInterval time.Duration   // Interval between two replica lag checks, defaults to 5 seconds
+	Consistency             ConsistencyMode // Read-your-writes mode for contexts created by WithConsistency
+	GTIDWaitTimeout         time.Duration   // Time a replica read waits for a previous write, defaults to 1 second
+	StickyWindow            time.Duration   // Time reads stay on the primary after a write with ConsistencySticky, defaults to 5 seconds
 }
 
 // Option is a function type used to apply configuration options.
@@ -38,6 +79,20 @@ type option struct {
 	maxIdleConn     int           // Maximum number of connections in the idle connection pool
 	maxOpenConn     int           // Maximum number of open connections to the database
 	connMaxLifetime time.Duration // Maximum amount of time a connection may be reused
+	connMaxIdleTime time.Duration // Maximum amount of time a connection may be idle
+	warmConns       int           // Number of connections opened and pinged during startup
+	tls             *TLSConfig    // Default TLS settings for configurations without their own
+	concurrency     int           // Maximum number of connections opened concurrently by NewMulti
+	retry           retryPolicy   // Retry policy for failed connection attempts
+	lazy            bool          // Whether dialing is deferred until the first query
+	healthTimeout   time.Duration // Timeout of each ping performed by health checks and the monitor
+	monitor         monitorOption // Settings of the background connection monitor
+	tenant          tenantOption  // Settings of tenant resolvers
+	drainTimeout    time.Duration // Time a pool replaced by Manager.Reload may finish its queries
+
+	statsRegistry StatsRegistry // Registry of the pool statistics, nil when disabled
+	metrics       MetricsSink   // Sink of the query metrics plugin, nil when disabled
+	metricsName   string        // Connection reported by the metrics plugin, defaults to the connection name
 }
 
 // WithConfigs returns an Option that sets the database configurations.
@@ -111,6 +166,44 @@ func WithConnMaxLifetime(connMaxLifetime time.Duration) Option {
 	}
 }
 
+// WithConnMaxIdleTime returns an Option that sets the maximum amount of time a connection may be idle.
+//
+// Parameters:
+//   - connMaxIdleTime: A time.Duration representing the maximum idle time of a connection.
+//
+// Returns:
+//   - An Option function that sets the maximum connection idle time when applied.
+//
+// Example:
+//
+//	db, err := New(WithConnMaxIdleTime(10 * time.Minute))
+func WithConnMaxIdleTime(connMaxIdleTime time.Duration) Option {
+	return func(o *option) {
+		o.connMaxIdleTime = connMaxIdleTime
+	}
+}
+
+// WithWarmConnections returns an Option that opens and pings n connections per database
+// during startup, so the first requests do not pay the connection setup latency.
+//
+// The number of warm connections is capped by the maximum idle and open connections,
+// since connections beyond those limits would be closed again immediately.
+//
+// Parameters:
+//   - n: The number of connections to open during startup.
+//
+// Returns:
+//   - An Option function that sets the number of warm connections when applied.
+//
+// Example:
+//
+//	db, err := New(WithConfigs(cfg), WithWarmConnections(5))
+func WithWarmConnections(n int) Option {
+	return func(o *option) {
+		o.warmConns = n
+	}
+}
+
 // WithMaxOpenConn returns an Option that sets the maximum number of open connections.
 //
 // Parameters:
@@ -128,6 +221,43 @@ func WithMaxOpenConn(maxOpenConn int) Option {
 	}
 }
 
+// WithConnectConcurrency returns an Option that sets how many connections NewMulti opens concurrently.
+//
+// Parameters:
+//   - n: The maximum number of connections opened at the same time. Values below 1 are treated as 1.
+//
+// Returns:
+//   - An Option function that sets the connect concurrency when applied.
+//
+// Example:
+//
+//	dbs, err := NewMulti(WithConfigs(cfgs...), WithConnectConcurrency(8))
+func WithConnectConcurrency(n int) Option {
+	return func(o *option) {
+		o.concurrency = n
+	}
+}
+
+// WithLazyConnect returns an Option that defers dialing until the first query.
+//
+// New and NewMulti only validate the configuration and return usable gorm.DB instances
+// without contacting the server: the automatic ping, the server version detection, the
+// warm-up and the connect retries are skipped. Connection errors surface on the first query.
+// Because the server version is not detected, version specific dialect features fall back
+// to the GORM MySQL driver defaults.
+//
+// Returns:
+//   - An Option function that enables lazy connections when applied.
+//
+// Example:
+//
+//	db, err := New(WithConfigs(cfg), WithLazyConnect())
+func WithLazyConnect() Option {
+	return func(o *option) {
+		o.lazy = true
+	}
+}
+
 // New initializes and returns a single database connection instance.
 //
 // Parameters:
@@ -145,48 +275,193 @@ func WithMaxOpenConn(maxOpenConn int) Option {
 //	    WithMaxOpenConn(75),
 //	)
 func New(opts ...Option) (*gorm.DB, error) {
+	return NewContext(context.Background(), opts...)
+}
+
+// NewContext is like New but stops connecting, including retries, when ctx is done.
+//
+// Parameters:
+//   - ctx: The context bounding the initialization.
+//   - opts: A variadic list of Option functions to configure the database connection.
+//
+// Returns:
+//   - A pointer to a gorm.DB instance representing the database connection.
+//   - An error if the initialization fails or ctx is done first.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	db, err := NewContext(ctx, WithConfigs(cfg), WithConnectRetry(20, time.Second, 10*time.Second))
+func NewContext(ctx context.Context, opts ...Option) (*gorm.DB, error) {
 	opt := setOption(opts...)
 	if len(opt.dbConfigs) != 1 {
 		return nil, errors.New("this method can only initialize one database connection instance")
 	}
 
-	return newConnect(&opt.dbConfigs[0], opt)
+	cfg := &opt.dbConfigs[0]
+	db, err := newConnect(ctx, cfg, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	dbs, cfgs := map[string]*gorm.DB{cfg.key(): db}, configsByKey(opt.dbConfigs)
+	if err = registerStats(opt, func() []PoolStats { return collectPoolStats(dbs, cfgs) }); err != nil {
+		_ = closeDB(db)
+		return nil, err
+	}
+
+	if err = startMonitor(opt, dbs, cfgs).attach(dbs); err != nil {
+		_ = closeDB(db)
+		return nil, err
+	}
+
+	return db, nil
 }
 
 // NewMulti initializes and returns multiple database connection instances.
 //
+// Connections are opened concurrently, bounded by WithConnectConcurrency. If any connection
+// fails, every connection that was opened successfully is closed again.
+//
 // Parameters:
 //   - opts: A variadic list of Option functions to configure the database connections.
 //
 // Returns:
-//   - A map with database names as keys and corresponding gorm.DB instances as values.
-//   - An error if the initialization fails.
+//   - A map with connection names as keys and corresponding gorm.DB instances as values.
+//     The key is Config.Name, or the database name when Name is empty.
+//   - An error if two configurations share the same key, or the joined errors of every
+//     connection that failed, each naming the connection and its host.
 //
 // Example:
 //
-//	cfg1 := Config{User: "user1", Password: "pass1", Host: "host1", DBName: "db1"}
-//	cfg2 := Config{User: "user2", Password: "pass2", Host: "host2", DBName: "db2"}
+//	cfg1 := Config{Name: "orders-eu", User: "user1", Password: "pass1", Host: "host1", DBName: "orders"}
+//	cfg2 := Config{Name: "orders-us", User: "user2", Password: "pass2", Host: "host2", DBName: "orders"}
 //	dbs, err := NewMulti(WithConfigs(cfg1, cfg2), WithMaxIdleConn(15))
 func NewMulti(opts ...Option) (map[string]*gorm.DB, error) {
+	return NewMultiContext(context.Background(), opts...)
+}
+
+// NewMultiContext is like NewMulti but stops connecting, including retries, when ctx is done.
+//
+// Parameters:
+//   - ctx: The context bounding the initialization.
+//   - opts: A variadic list of Option functions to configure the database connections.
+//
+// Returns:
+//   - A map with connection names as keys and corresponding gorm.DB instances as values.
+//   - An error if the initialization fails or ctx is done first.
+func NewMultiContext(ctx context.Context, opts ...Option) (map[string]*gorm.DB, error) {
 	opt := setOption(opts...)
 
-	if len(opt.dbConfigs) < 1 {
-		return nil, errors.New("the number of database configurations to initialize cannot be 0")
+	dbs, err := newMulti(ctx, opt)
+	if err != nil {
+		return nil, err
 	}
 
-	dbs := make(map[string]*gorm.DB)
-	for _, cfg := range opt.dbConfigs {
-		conn, err := newConnect(&cfg, opt)
-		if err != nil {
-			return nil, err
-		}
+	cfgs := configsByKey(opt.dbConfigs)
+	if err = registerStats(opt, func() []PoolStats { return collectPoolStats(dbs, cfgs) }); err != nil {
+		_ = closeAll(dbs)
+		return nil, err
+	}
 
-		dbs[cfg.DBName] = conn
+	if err = startMonitor(opt, dbs, cfgs).attach(dbs); err != nil {
+		_ = closeAll(dbs)
+		return nil, err
 	}
 
 	return dbs, nil
 }
 
+// newMulti opens every configured database connection.
+//
+// Parameters:
+//   - ctx: The context bounding the initialization.
+//   - opt: A pointer to the option struct holding the configurations and settings.
+//
+// Returns:
+//   - A map with connection names as keys and corresponding gorm.DB instances as values.
+//   - An error if the configurations are invalid or any connection fails.
+func newMulti(ctx context.Context, opt *option) (map[string]*gorm.DB, error) {
+	if err := checkConfigs(opt.dbConfigs); err != nil {
+		return nil, err
+	}
+
+	concurrency := opt.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+		dbs  = make(map[string]*gorm.DB, len(opt.dbConfigs))
+	)
+
+	for i := range opt.dbConfigs {
+		cfg := &opt.dbConfigs[i]
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			conn, err := newConnect(ctx, cfg, opt)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("connect %q (%s): %w", cfg.key(), cfg.host(), err))
+				return
+			}
+
+			dbs[cfg.key()] = conn
+		}()
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		// Close the pools that were opened successfully so they are not leaked
+		_ = closeAll(dbs)
+
+		return nil, errors.Join(errs...)
+	}
+
+	return dbs, nil
+}
+
+// checkConfigs checks that there is at least one configuration and that connection names
+// are unique.
+//
+// Parameters:
+//   - cfgs: The configurations to check.
+//
+// Returns:
+//   - An error if the configurations cannot be opened together.
+func checkConfigs(cfgs []Config) error {
+	if len(cfgs) < 1 {
+		return errors.New("the number of database configurations to initialize cannot be 0")
+	}
+
+	seen := make(map[string]bool, len(cfgs))
+	for _, cfg := range cfgs {
+		name := cfg.key()
+		if seen[name] {
+			return fmt.Errorf("duplicate database connection name %q", name)
+		}
+
+		seen[name] = true
+	}
+
+	return nil
+}
+
 // setOption applies the provided options and returns the resulting option struct.
 //
 // Parameters:
@@ -199,6 +474,8 @@ func setOption(opts ...Option) *option {
 		maxIdleConn:     defaultMaxIdleConn,
 		maxOpenConn:     defaultMaxOpenConn,
 		connMaxLifetime: defaultConnMaxLifetime,
+		concurrency:     defaultConnectConcurrency,
+		healthTimeout:   defaultHealthTimeout,
 	}
 
 	for _, f := range opts {
@@ -211,22 +488,55 @@ func setOption(opts ...Option) *option {
 // newConnect creates a new database connection with the given configuration and options.
 //
 // Parameters:
+//   - ctx: The context bounding the connection attempts.
 //   - cfg: A pointer to a Config struct containing database connection details.
 //   - opt: A pointer to an option struct containing additional configuration options.
 //
 // Returns:
 //   - A pointer to a gorm.DB instance representing the database connection.
 //   - An error if the connection fails.
-func newConnect(cfg *Config, opt *option) (*gorm.DB, error) {
+func newConnect(ctx context.Context, cfg *Config, opt *option) (*gorm.DB, error) {
+	// Apply the global defaults without modifying the caller's configuration
+	c := cfg.withDefaults(opt)
+
 	// Construct the DSN (Data Source Name) string
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.DBName)
-
-	// Open the database connection
-	db, err := gorm.Open(mysql.Open(dsn), &opt.gormConfig)
+	dsn, err := c.FormatDSN()
+	if err != nil {
+		return nil, err
+	}
+
+	var db *gorm.DB
+	if opt.lazy {
+		// Validate the DSN and open the pool without contacting the server
+		if _, err = gomysql.ParseDSN(dsn); err != nil {
+			return nil, err
+		}
+
+		c.GormConfig.DisableAutomaticPing = true
+		c.WarmConnections = 0
+
+		var dialector gorm.Dialector
+		if dialector, err = c.dialector(ctx, dsn, true); err != nil {
+			return nil, err
+		}
+		db, err = gorm.Open(dialector, c.GormConfig)
+	} else {
+		// Open the database connection, retrying according to the retry policy
+		db, err = opt.retry.do(ctx, c.logger(), c.key(), c.host(), func() (*gorm.DB, error) {
+			dialector, err := c.dialector(ctx, dsn, false)
+			if err != nil {
+				return nil, err
+			}
+
+			db, err := gorm.Open(dialector, c.GormConfig)
+			if err != nil && db != nil && db.ConnPool != nil {
+				// Release the pool of the failed attempt before the next one opens a new pool
+				_ = closeDB(db)
+			}
+
+			return db, err
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -238,9 +548,248 @@ func newConnect(cfg *Config, opt *option) (*gorm.DB, error) {
 	}
 
 	// Configure the connection pool
-	sqlDB.SetMaxIdleConns(opt.maxIdleConn)        // Set the maximum number of connections in the idle connection pool
-	sqlDB.SetMaxOpenConns(opt.maxOpenConn)        // Set the maximum number of open connections to the database
-	sqlDB.SetConnMaxLifetime(opt.connMaxLifetime) // Set the maximum amount of time a connection may be reused
+	sqlDB.SetMaxIdleConns(c.MaxIdleConn)        // Set the maximum number of connections in the idle connection pool
+	sqlDB.SetMaxOpenConns(c.MaxOpenConn)        // Set the maximum number of open connections to the database
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime) // Set the maximum amount of time a connection may be reused
+	sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime) // Set the maximum amount of time a connection may be idle
+
+	// Pre-open connections so the first requests do not pay the setup latency
+	if err = warmUp(ctx, sqlDB, c.warmConnections()); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
+	// Record the latency and errors of every operation, if enabled
+	if opt.metrics != nil {
+		name := c.key()
+		if opt.metricsName != "" {
+			name = opt.metricsName
+		}
+
+		if err = db.Use(NewMetricsPlugin(name, opt.metrics)); err != nil {
+			_ = sqlDB.Close()
+			return nil, err
+		}
+	}
+
+	// Route reads to the replicas, if any
+	if len(c.Replicas) > 0 {
+		if err = openReplicas(ctx, db, &c, opt); err != nil {
+			_ = sqlDB.Close()
+			return nil, err
+		}
+	}
 
 	return db, nil
 }
+
+// dialector returns the GORM dialector for the configuration.
+//
+// Parameters:
+//   - ctx: The context bounding the selection of the writable host when Hosts is set.
+//   - dsn: The DSN of the configuration.
+//   - lazy: Whether the server must not be contacted.
+//
+// Returns:
+//   - The dialector.
+//   - An error if the dialector cannot be created.
+func (c *Config) dialector(ctx context.Context, dsn string, lazy bool) (gorm.Dialector, error) {
+	if len(c.Hosts) > 0 && c.DSN == "" {
+		return c.failoverDialector(ctx, lazy)
+	}
+
+	if c.Credentials != nil {
+		return c.credentialDialector(dsn, lazy)
+	}
+
+	return mysql.New(mysql.Config{DSN: dsn, SkipInitializeWithVersion: lazy}), nil
+}
+
+// warmUp opens and pings n connections and returns them to the idle pool.
+//
+// Parameters:
+//   - ctx: The context bounding the warm-up.
+//   - sqlDB: The connection pool to warm up.
+//   - n: The number of connections to open.
+//
+// Returns:
+//   - An error if any connection cannot be opened or pinged.
+func warmUp(ctx context.Context, sqlDB *sql.DB, n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close() // Returns the connection to the idle pool
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("warm up connection %d: %w", i+1, err)
+		}
+
+		conns = append(conns, conn)
+		if err = conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("warm up connection %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// warmConnections returns the number of connections to warm up, capped by the pool limits.
+//
+// Returns:
+//   - The number of connections to open during startup.
+func (c *Config) warmConnections() int {
+	n := c.WarmConnections
+	if c.MaxIdleConn > 0 && n > c.MaxIdleConn {
+		n = c.MaxIdleConn
+	}
+
+	if c.MaxOpenConn > 0 && n > c.MaxOpenConn {
+		n = c.MaxOpenConn
+	}
+
+	return n
+}
+
+// logger returns the GORM logger of the configuration, falling back to the GORM default.
+//
+// Returns:
+//   - The gormlogger.Interface used to report connection events.
+func (c *Config) logger() gormlogger.Interface {
+	if c.GormConfig != nil && c.GormConfig.Logger != nil {
+		return c.GormConfig.Logger
+	}
+
+	return gormlogger.Default
+}
+
+// logger returns the logger configured with WithGormConfig for messages that do not
+// belong to a single connection.
+//
+// Returns:
+//   - The GORM logger, or gormlogger.Default if none is configured.
+func (o *option) logger() gormlogger.Interface {
+	if o.gormConfig.Logger != nil {
+		return o.gormConfig.Logger
+	}
+
+	return gormlogger.Default
+}
+
+// Close closes a connection returned by New or NewMulti together with everything attached
+// to it: its read replicas, their lag checker and its connection monitor. Closing only the
+// underlying sql.DB leaves those running.
+//
+// Parameters:
+//   - db: The connection to close.
+//
+// Returns:
+//   - An error if the pool cannot be retrieved or closed.
+//
+// Example:
+//
+//	db, err := New(WithConfigs(cfg), WithMonitor(10*time.Second))
+//	defer Close(db)
+func Close(db *gorm.DB) error {
+	return closeDB(db)
+}
+
+// closeDB closes the database/sql pool underlying a gorm.DB, together with the resources
+// owned by its plugins, such as replica pools.
+//
+// Parameters:
+//   - db: The gorm.DB whose connection pool should be closed.
+//
+// Returns:
+//   - An error if the pool cannot be retrieved or closed.
+func closeDB(db *gorm.DB) error {
+	var errs []error
+	for _, plugin := range db.Config.Plugins {
+		if c, ok := plugin.(closer); ok {
+			errs = append(errs, c.close())
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	return errors.Join(append(errs, sqlDB.Close())...)
+}
+
+// key returns the name under which the connection is stored by NewMulti.
+//
+// Returns:
+//   - Name, or the database name when Name is empty.
+func (c *Config) key() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return c.dbName()
+}
+
+// withDefaults returns a copy of the configuration in which every unset per-connection
+// setting is filled in from the global options.
+//
+// Parameters:
+//   - opt: A pointer to the option struct holding the global settings.
+//
+// Returns:
+//   - A Config with all overridable settings resolved.
+func (c *Config) withDefaults(opt *option) Config {
+	resolved := *c
+
+	if resolved.TLS == nil {
+		resolved.TLS = opt.tls
+	}
+
+	if resolved.MaxIdleConn <= 0 {
+		resolved.MaxIdleConn = opt.maxIdleConn
+	}
+
+	if resolved.MaxOpenConn <= 0 {
+		resolved.MaxOpenConn = opt.maxOpenConn
+	}
+
+	if resolved.ConnMaxLifetime <= 0 {
+		resolved.ConnMaxLifetime = opt.connMaxLifetime
+	}
+
+	if resolved.ConnMaxIdleTime <= 0 {
+		resolved.ConnMaxIdleTime = opt.connMaxIdleTime
+	}
+
+	if resolved.WarmConnections <= 0 {
+		resolved.WarmConnections = opt.warmConns
+	}
+
+	// Copy the GORM configuration so that connections never share mutable state
+	gormCfg := opt.gormConfig
+	if c.GormConfig != nil {
+		gormCfg = *c.GormConfig
+		if gormCfg.Logger == nil {
+			gormCfg.Logger = opt.gormConfig.Logger
+		}
+	}
+
+	if gormCfg.Plugins != nil {
+		plugins := make(map[string]gorm.Plugin, len(gormCfg.Plugins))
+		for name, plugin := range gormCfg.Plugins {
+			plugins[name] = plugin
+		}
+		gormCfg.Plugins = plugins
+	}
+
+	resolved.GormConfig = &gormCfg
+
+	return resolved
+}
@@ -3,7 +3,11 @@ package mysql
 import (
 	"fmt"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type Product struct {
@@ -69,3 +73,91 @@ func TestMysqlMulti(t *testing.T) {
 
 	fmt.Printf("Test mysql_test2 Success output:%v", product2)
 }
+
+func TestMysqlMultiDuplicateName(t *testing.T) {
+	cfg1 := Config{Host: "127.0.0.1:33060", DBName: "orders"}
+	cfg2 := Config{Host: "127.0.0.2:33060", DBName: "orders"}
+
+	_, err := NewMulti(WithConfigs(cfg1, cfg2))
+	if err == nil || !strings.Contains(err.Error(), `"orders"`) {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+}
+
+func TestMysqlMultiJoinedErrors(t *testing.T) {
+	cfg1 := Config{Name: "first", Host: "127.0.0.1:1", DBName: "mysql_test", Timeout: time.Second}
+	cfg2 := Config{Name: "second", Host: "127.0.0.1:2", DBName: "mysql_test", Timeout: time.Second}
+
+	_, err := NewMulti(WithConfigs(cfg1, cfg2), WithConnectConcurrency(2))
+	if err == nil {
+		t.Fatal("expected connection errors")
+	}
+
+	for _, want := range []string{`"first" (127.0.0.1:1)`, `"second" (127.0.0.1:2)`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestConfigWithDefaults(t *testing.T) {
+	opt := setOption(
+		WithMaxOpenConn(200),
+		WithGormConfig(gorm.Config{Logger: gormlogger.Discard, Plugins: map[string]gorm.Plugin{}}),
+	)
+
+	oltp := Config{Name: "oltp"}
+	analytics := Config{Name: "analytics", MaxOpenConn: 5, GormConfig: &gorm.Config{PrepareStmt: true}}
+
+	resolved := oltp.withDefaults(opt)
+	if resolved.MaxOpenConn != 200 || resolved.GormConfig.PrepareStmt {
+		t.Errorf("global settings not applied: %+v", resolved)
+	}
+
+	if resolved.GormConfig == &opt.gormConfig || reflect.ValueOf(resolved.GormConfig.Plugins).Pointer() == reflect.ValueOf(opt.gormConfig.Plugins).Pointer() {
+		t.Error("GORM configuration is shared with the global options")
+	}
+
+	resolved = analytics.withDefaults(opt)
+	if resolved.MaxOpenConn != 5 || !resolved.GormConfig.PrepareStmt || resolved.GormConfig.Logger != gormlogger.Discard {
+		t.Errorf("per-connection overrides not applied: %+v", resolved)
+	}
+}
+
+func TestConfigWarmConnections(t *testing.T) {
+	opt := setOption(WithWarmConnections(20), WithMaxIdleConn(8), WithConnMaxIdleTime(time.Minute))
+
+	cfg := Config{}
+	resolved := cfg.withDefaults(opt)
+	if got := resolved.warmConnections(); got != 8 {
+		t.Errorf("warmConnections() = %d, want 8", got)
+	}
+
+	if resolved.ConnMaxIdleTime != time.Minute {
+		t.Errorf("ConnMaxIdleTime = %v, want %v", resolved.ConnMaxIdleTime, time.Minute)
+	}
+
+	cfg = Config{WarmConnections: 3}
+	resolved = cfg.withDefaults(opt)
+	if got := resolved.warmConnections(); got != 3 {
+		t.Errorf("warmConnections() = %d, want 3", got)
+	}
+}
+
+func TestMysqlLazyConnect(t *testing.T) {
+	cfg := Config{User: "homestead", Host: "127.0.0.1:1", DBName: "mysql_test", Timeout: time.Second}
+
+	db, err := New(WithConfigs(cfg), WithLazyConnect(), WithWarmConnections(2))
+	if err != nil {
+		t.Fatal("lazy connect should not dial:", err)
+	}
+
+	var product Product
+	if err = db.First(&product, 1).Error; err == nil {
+		t.Fatal("expected the first query to surface the connection error")
+	}
+
+	if _, err = New(WithConfigs(Config{DSN: "not a dsn"}), WithLazyConnect()); err == nil {
+		t.Fatal("expected an invalid DSN to be rejected")
+	}
+}
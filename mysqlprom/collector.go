@@ -0,0 +1,108 @@
+// Package mysqlprom exports the connection pool statistics and query metrics of
+// github.com/sk-pkg/mysql to Prometheus. It is a separate package so that programs that do
+// not use Prometheus do not depend on its client library.
+package mysqlprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sk-pkg/mysql"
+)
+
+// statsLabels are the variable labels of every pool statistics metric.
+var statsLabels = []string{"connection", "host"}
+
+// Descriptions of the pool statistics metrics, one per sql.DBStats field.
+var (
+	statsMaxOpenDesc = prometheus.NewDesc("mysql_pool_max_open_connections",
+		"Maximum number of open connections to the database.", statsLabels, nil)
+	statsOpenDesc = prometheus.NewDesc("mysql_pool_open_connections",
+		"Number of established connections, both in use and idle.", statsLabels, nil)
+	statsInUseDesc = prometheus.NewDesc("mysql_pool_in_use_connections",
+		"Number of connections currently in use.", statsLabels, nil)
+	statsIdleDesc = prometheus.NewDesc("mysql_pool_idle_connections",
+		"Number of idle connections.", statsLabels, nil)
+	statsWaitCountDesc = prometheus.NewDesc("mysql_pool_wait_count_total",
+		"Total number of connections waited for.", statsLabels, nil)
+	statsWaitDurationDesc = prometheus.NewDesc("mysql_pool_wait_duration_seconds_total",
+		"Total time blocked waiting for a new connection.", statsLabels, nil)
+	statsMaxIdleClosedDesc = prometheus.NewDesc("mysql_pool_max_idle_closed_total",
+		"Total number of connections closed due to the maximum number of idle connections.", statsLabels, nil)
+	statsMaxIdleTimeClosedDesc = prometheus.NewDesc("mysql_pool_max_idle_time_closed_total",
+		"Total number of connections closed due to the maximum idle time.", statsLabels, nil)
+	statsMaxLifetimeClosedDesc = prometheus.NewDesc("mysql_pool_max_lifetime_closed_total",
+		"Total number of connections closed due to the maximum connection lifetime.", statsLabels, nil)
+)
+
+// WithStatsCollector returns a mysql.Option that registers a Prometheus collector exporting
+// the sql.DBStats of every connection, and of its read replicas, labelled by connection name
+// and host.
+//
+// The collector is unchecked, so several New, NewMulti or NewManager calls may register
+// theirs with the same registerer as long as their connection names differ. Collectors
+// registered by NewManager follow Reload and stop reporting when the Manager is closed;
+// those registered by New or NewMulti report their pools for the lifetime of the registerer.
+//
+// Parameters:
+//   - reg: The registerer the collector is registered with, e.g. prometheus.DefaultRegisterer.
+//
+// Returns:
+//   - A mysql.Option that enables the collector when applied.
+//
+// Example:
+//
+//	m, err := mysql.NewManager(mysql.WithConfigs(cfg1, cfg2), mysqlprom.WithStatsCollector(prometheus.DefaultRegisterer))
+func WithStatsCollector(reg prometheus.Registerer) mysql.Option {
+	return mysql.WithStatsRegistry(statsRegistry{reg: reg})
+}
+
+// statsRegistry is a mysql.StatsRegistry registering a collector per set of pools.
+type statsRegistry struct {
+	reg prometheus.Registerer
+}
+
+// RegisterPools registers a collector exporting the given pools.
+//
+// Parameters:
+//   - pools: The function returning the statistics of the pools at collection time.
+//
+// Returns:
+//   - An error if the collector cannot be registered.
+func (r statsRegistry) RegisterPools(pools func() []mysql.PoolStats) error {
+	return r.reg.Register(&statsCollector{pools: pools})
+}
+
+// statsCollector is a prometheus.Collector exporting the sql.DBStats of a set of pools.
+type statsCollector struct {
+	pools func() []mysql.PoolStats // Returns the pools to export at collection time
+}
+
+// Describe sends no descriptors, which makes the collector unchecked so that several
+// collectors may share a registerer.
+func (c *statsCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends the current statistics of every pool.
+//
+// Parameters:
+//   - ch: The channel receiving the metrics.
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, p := range c.pools() {
+		s := p.Stats
+
+		gauge := func(desc *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, p.Connection, p.Host)
+		}
+		counter := func(desc *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, p.Connection, p.Host)
+		}
+
+		gauge(statsMaxOpenDesc, float64(s.MaxOpenConnections))
+		gauge(statsOpenDesc, float64(s.OpenConnections))
+		gauge(statsInUseDesc, float64(s.InUse))
+		gauge(statsIdleDesc, float64(s.Idle))
+		counter(statsWaitCountDesc, float64(s.WaitCount))
+		counter(statsWaitDurationDesc, s.WaitDuration.Seconds())
+		counter(statsMaxIdleClosedDesc, float64(s.MaxIdleClosed))
+		counter(statsMaxIdleTimeClosedDesc, float64(s.MaxIdleTimeClosed))
+		counter(statsMaxLifetimeClosedDesc, float64(s.MaxLifetimeClosed))
+	}
+}
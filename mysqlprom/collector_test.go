@@ -0,0 +1,80 @@
+package mysqlprom
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sk-pkg/mysql"
+	"reflect"
+	"testing"
+)
+
+// gatherSeries returns the connection and host labels of every series of a metric.
+func gatherSeries(t *testing.T, reg *prometheus.Registry, metric string) map[string]string {
+	t.Helper()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	series := make(map[string]string)
+	for _, family := range families {
+		if family.GetName() != metric {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			series[labels["connection"]] = labels["host"]
+		}
+	}
+
+	return series
+}
+
+func TestStatsCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	cfg := mysql.Config{User: "user", Host: "127.0.0.1:1", DBName: "orders",
+		Replicas: []mysql.Config{{Host: "127.0.0.2:1"}}}
+	m, err := mysql.NewManager(mysql.WithConfigs(cfg), mysql.WithLazyConnect(), mysql.WithMaxOpenConn(7), WithStatsCollector(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"orders": "127.0.0.1:1", "orders-replica-1": "127.0.0.2:1"}
+	if got := gatherSeries(t, reg, "mysql_pool_max_open_connections"); !reflect.DeepEqual(got, want) {
+		t.Errorf("series = %v, want %v", got, want)
+	}
+
+	families, _ := reg.Gather()
+	if len(families) != 9 {
+		t.Errorf("gathered %d metrics, want 9", len(families))
+	}
+
+	for _, family := range families {
+		if family.GetName() == "mysql_pool_max_open_connections" && family.GetMetric()[0].GetGauge().GetValue() != 7 {
+			t.Errorf("max open connections = %v, want 7", family.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+
+	// A second set of connections can share the registerer
+	dbs, err := mysql.NewMulti(mysql.WithConfigs(mysql.Config{User: "user", Host: "127.0.0.3:1", DBName: "users"}),
+		mysql.WithLazyConnect(), WithStatsCollector(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mysql.Close(dbs["users"]) }()
+
+	if got := gatherSeries(t, reg, "mysql_pool_open_connections"); len(got) != 3 || got["users"] != "127.0.0.3:1" {
+		t.Errorf("series = %v", got)
+	}
+
+	_ = m.Close(context.Background())
+	if got := gatherSeries(t, reg, "mysql_pool_open_connections"); len(got) != 1 {
+		t.Errorf("closed Manager is still reported: %v", got)
+	}
+}
@@ -0,0 +1,91 @@
+package mysqlprom
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sk-pkg/mysql"
+)
+
+// metricsLabels are the variable labels of every query metric.
+var metricsLabels = []string{"connection", "table", "operation"}
+
+// PrometheusSink is a mysql.MetricsSink recording a latency histogram and an error counter in
+// Prometheus, labelled by connection, table and operation.
+type PrometheusSink struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewPrometheusSink returns a mysql.MetricsSink exporting mysql_query_duration_seconds and
+// mysql_query_errors_total. Creating a second sink on the same registerer reuses the
+// metrics registered by the first one.
+//
+// Parameters:
+//   - reg: The registerer the metrics are registered with, e.g. prometheus.DefaultRegisterer.
+//   - buckets: The histogram buckets in seconds, or nil for prometheus.DefBuckets.
+//
+// Returns:
+//   - The sink.
+//   - An error if the metrics cannot be registered.
+//
+// Example:
+//
+//	sink, err := NewPrometheusSink(prometheus.DefaultRegisterer, []float64{.001, .005, .01, .05, .1, .5, 1})
+//	m, err := mysql.NewManager(mysql.WithConfigs(cfg1, cfg2), mysql.WithMetrics(sink))
+func NewPrometheusSink(reg prometheus.Registerer, buckets []float64) (*PrometheusSink, error) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mysql_query_duration_seconds",
+		Help:    "Latency of GORM operations.",
+		Buckets: buckets,
+	}, metricsLabels)
+
+	errs := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_query_errors_total",
+		Help: "Total number of failed GORM operations.",
+	}, metricsLabels)
+
+	duration, err := registerOrExisting(reg, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs, err = registerOrExisting(reg, errs); err != nil {
+		return nil, err
+	}
+
+	return &PrometheusSink{duration: duration, errors: errs}, nil
+}
+
+// registerOrExisting registers a collector, or returns the equal collector that is
+// already registered.
+//
+// Parameters:
+//   - reg: The registerer.
+//   - c: The collector to register.
+//
+// Returns:
+//   - The registered collector.
+//   - An error if the collector cannot be registered.
+func registerOrExisting[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	err := reg.Register(c)
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+
+	return c, err
+}
+
+// ObserveQuery records the latency of an operation and counts it if it failed.
+//
+// Parameters:
+//   - m: The metric of the finished operation.
+func (s *PrometheusSink) ObserveQuery(m mysql.QueryMetric) {
+	s.duration.WithLabelValues(m.Connection, m.Table, m.Operation).Observe(m.Duration.Seconds())
+	if m.Err != nil {
+		s.errors.WithLabelValues(m.Connection, m.Table, m.Operation).Inc()
+	}
+}
@@ -0,0 +1,46 @@
+package mysqlprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sk-pkg/mysql"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestPrometheusSink(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	sink, err := NewPrometheusSink(reg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A second sink reuses the registered metrics
+	again, err := NewPrometheusSink(reg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink.ObserveQuery(mysql.QueryMetric{Connection: "orders", Table: "orders", Operation: "query"})
+	again.ObserveQuery(mysql.QueryMetric{Connection: "orders", Table: "orders", Operation: "query", Err: gorm.ErrInvalidData})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if h := m.GetHistogram(); h != nil {
+				counts[family.GetName()] += float64(h.GetSampleCount())
+			} else {
+				counts[family.GetName()] += m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	if counts["mysql_query_duration_seconds"] != 2 || counts["mysql_query_errors_total"] != 1 {
+		t.Errorf("unexpected metrics: %v", counts)
+	}
+}
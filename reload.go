@@ -0,0 +1,396 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultDrainTimeout is the default time a replaced pool may keep running queries
+	// before it is closed.
+	defaultDrainTimeout = 30 * time.Second
+	// drainPollInterval is the interval at which a replaced pool is checked for running queries.
+	drainPollInterval = 100 * time.Millisecond
+)
+
+// WithDrainTimeout returns an Option that sets how long Manager.Reload waits for a replaced
+// or removed pool, and its read replicas, to finish their running queries before closing
+// them. Closing a pool still waits for the queries running at that time, so the timeout is
+// not an upper bound on how long the old pools live.
+//
+// Parameters:
+//   - d: The drain timeout.
+//
+// Returns:
+//   - An Option function that sets the drain timeout when applied.
+//
+// Example:
+//
+//	m, err := NewManager(WithConfigs(cfg), WithDrainTimeout(time.Minute))
+func WithDrainTimeout(d time.Duration) Option {
+	return func(o *option) {
+		o.drainTimeout = d
+	}
+}
+
+// Reload replaces the configurations of the Manager at runtime. Connections whose
+// configuration is unchanged keep their pool. New and changed connections are opened
+// first; if any of them fails, nothing is replaced. Callers of Get see the new pools as
+// soon as Reload returns, while the replaced and removed pools are closed in the
+// background once their running queries have finished, or once the drain timeout has
+// passed, in which case closing waits for the queries that are still running.
+// The names of added, removed and changed connections, and of the changed fields, are
+// logged through the GORM logger; values are not logged.
+//
+// Parameters:
+//   - ctx: The context bounding the connection attempts.
+//   - cfgs: The complete new set of configurations.
+//
+// Returns:
+//   - An error if the configurations are invalid, a connection fails, or the Manager is closed.
+//
+// Example:
+//
+//	cfg.Password = rotatedPassword
+//	if err := m.Reload(ctx, cfg); err != nil {
+//	    log.Println("reload failed, keeping the current connections:", err)
+//	}
+func (m *Manager) Reload(ctx context.Context, cfgs ...Config) error {
+	if err := checkConfigs(cfgs); err != nil {
+		return err
+	}
+
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return ErrManagerClosed
+	}
+	current := m.cfgs
+	m.mu.RUnlock()
+
+	next := configsByKey(cfgs)
+
+	var (
+		open             []Config
+		added, removed   []string
+		changed, retired []string
+	)
+	for _, cfg := range cfgs {
+		name := cfg.key()
+		old, ok := current[name]
+		if !ok {
+			added = append(added, name)
+			open = append(open, cfg)
+			continue
+		}
+
+		if fields := configDiff(old, cfg); len(fields) > 0 {
+			changed = append(changed, fmt.Sprintf("%s (%s)", name, strings.Join(fields, ", ")))
+			retired = append(retired, name)
+			open = append(open, cfg)
+		}
+	}
+
+	for name := range current {
+		if _, ok := next[name]; !ok {
+			removed = append(removed, name)
+			retired = append(retired, name)
+		}
+	}
+
+	if len(open) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	opened := map[string]*gorm.DB{}
+	if len(open) > 0 {
+		o := *m.opt
+		o.dbConfigs = open
+
+		var err error
+		if opened, err = newMulti(ctx, &o); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		_ = closeAll(opened)
+		return ErrManagerClosed
+	}
+
+	dbs := make(map[string]*gorm.DB, len(next))
+	for name, db := range m.dbs {
+		if _, ok := next[name]; ok {
+			dbs[name] = db
+		}
+	}
+
+	old := make(map[string]*gorm.DB, len(retired))
+	for _, name := range retired {
+		old[name] = m.dbs[name]
+	}
+
+	for name, db := range opened {
+		dbs[name] = db
+	}
+
+	mon := m.mon
+	m.dbs, m.cfgs = dbs, next
+	m.mon = startMonitor(m.opt, dbs, next)
+	if len(old) > 0 {
+		// Registered under the lock so that a concurrent Close waits for the drain
+		m.draining.Add(1)
+	}
+	m.mu.Unlock()
+
+	mon.Stop()
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	m.opt.logger().Info(ctx, "reloaded database connections: added [%s], removed [%s], changed [%s]",
+		strings.Join(added, ", "), strings.Join(removed, ", "), strings.Join(changed, ", "))
+
+	m.drain(old)
+
+	return nil
+}
+
+// WatchFile polls a configuration file at the given interval and reloads the Manager with
+// its connections whenever the file content changes. Only the connections section is
+// reloaded; top-level pool, GORM and logger settings apply at startup, and a warning naming
+// them is logged when they change. Set pool settings per connection to tune them without a
+// restart. Invalid files and
+// failed reloads are logged and the current connections are kept; an invalid file is
+// skipped until it changes again, while a reload that failed to connect is retried at the
+// next interval. WatchFile blocks until ctx is done or the Manager is closed.
+//
+// Parameters:
+//   - ctx: The context stopping the watch.
+//   - path: The path of the YAML or JSON configuration file.
+//   - interval: The time between two checks of the file.
+//
+// Returns:
+//   - The context error, or ErrManagerClosed once the Manager is closed.
+//
+// Example:
+//
+//	go m.WatchFile(ctx, "config/database.yaml", 10*time.Second)
+func (m *Manager) WatchFile(ctx context.Context, path string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		last []byte
+		prev *FileConfig
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.done:
+			return ErrManagerClosed
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			m.opt.logger().Error(ctx, "watch %s: %v", path, err)
+			continue
+		}
+
+		if last != nil && bytes.Equal(data, last) {
+			continue
+		}
+
+		fc, cfgs, err := fileConfigs(path)
+		if err != nil {
+			// Retrying cannot fix the file, wait until it changes
+			last = data
+			m.opt.logger().Error(ctx, "reload %s: %v", path, err)
+			continue
+		}
+
+		if changed := fileSettingsDiff(prev, fc); len(changed) > 0 {
+			m.opt.logger().Warn(ctx, "reload %s: top-level settings [%s] changed but only apply at startup",
+				path, strings.Join(changed, ", "))
+		}
+		prev = fc
+
+		err = m.Reload(ctx, cfgs...)
+		if errors.Is(err, ErrManagerClosed) {
+			return err
+		}
+
+		if err != nil {
+			m.opt.logger().Error(ctx, "reload %s: %v", path, err)
+			continue
+		}
+		last = data
+	}
+}
+
+// fileConfigs loads and validates the connections of a configuration file.
+//
+// Parameters:
+//   - path: The path of the configuration file.
+//
+// Returns:
+//   - The parsed file.
+//   - The configurations of the connections.
+//   - An error if the file cannot be parsed or its connections are invalid.
+func fileConfigs(path string) (*FileConfig, []Config, error) {
+	fc, err := LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfgs, err := fc.Configs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = checkConfigs(cfgs); err != nil {
+		return nil, nil, err
+	}
+
+	return fc, cfgs, nil
+}
+
+// fileSettingsDiff returns the keys of the top-level settings, other than the connections,
+// that differ between two versions of a configuration file.
+//
+// Parameters:
+//   - a: The previous file, or nil if there is none.
+//   - b: The new file.
+//
+// Returns:
+//   - The file keys of the differing settings, in declaration order, or nil if a is nil.
+func fileSettingsDiff(a, b *FileConfig) []string {
+	if a == nil {
+		return nil
+	}
+
+	va, vb := reflect.ValueOf(*a), reflect.ValueOf(*b)
+
+	var keys []string
+	for i := 0; i < va.NumField(); i++ {
+		field := va.Type().Field(i)
+		if field.Name == "Connections" {
+			continue
+		}
+
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			keys = append(keys, field.Tag.Get("yaml"))
+		}
+	}
+
+	return keys
+}
+
+// drain closes the given pools in the background once neither they nor their replicas have
+// running queries, or once the drain timeout has passed. Closing a pool still waits for its
+// running queries. The caller must have added the drain to m.draining, which Manager.Close
+// waits for.
+//
+// Parameters:
+//   - dbs: The replaced pools, keyed by connection name.
+func (m *Manager) drain(dbs map[string]*gorm.DB) {
+	if len(dbs) == 0 {
+		return
+	}
+
+	timeout := m.opt.drainTimeout
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+
+	go func() {
+		defer m.draining.Done()
+
+		deadline := time.Now().Add(timeout)
+		for name, db := range dbs {
+			for inUse(db) > 0 && time.Now().Before(deadline) {
+				time.Sleep(drainPollInterval)
+			}
+
+			if err := closeDB(db); err != nil {
+				m.opt.logger().Error(context.Background(), "close replaced connection %q: %v", name, err)
+			}
+		}
+	}()
+}
+
+// inUse returns the number of connections in use in a pool and in its read replica pools.
+//
+// Parameters:
+//   - db: The gorm.DB owning the pool.
+//
+// Returns:
+//   - The number of in-use connections.
+func inUse(db *gorm.DB) int {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0
+	}
+
+	n := sqlDB.Stats().InUse
+	if r, ok := db.Config.Plugins[resolverPluginName].(*resolver); ok {
+		for _, rep := range r.replicas {
+			n += rep.sqlDB.Stats().InUse
+		}
+	}
+
+	return n
+}
+
+// configDiff returns the names of the Config fields that differ between two configurations.
+// Credential providers are compared with sameCredentials, so that reloading a file does
+// not reopen pools whose password file is unchanged.
+//
+// Parameters:
+//   - a: The current configuration.
+//   - b: The new configuration.
+//
+// Returns:
+//   - The names of the differing fields, in declaration order.
+func configDiff(a, b Config) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+
+	var fields []string
+	for i := 0; i < va.NumField(); i++ {
+		name := va.Type().Field(i).Name
+
+		var equal bool
+		switch name {
+		case "Credentials":
+			equal = sameCredentials(a.Credentials, b.Credentials)
+		case "Replicas":
+			equal = len(a.Replicas) == len(b.Replicas)
+			for j := 0; equal && j < len(a.Replicas); j++ {
+				equal = len(configDiff(a.Replicas[j], b.Replicas[j])) == 0
+			}
+		default:
+			equal = reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface())
+		}
+
+		if !equal {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
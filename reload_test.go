@@ -0,0 +1,207 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManagerReload(t *testing.T) {
+	m := newTestManager(t, "users", "orders")
+	defer func() { _ = m.Close(context.Background()) }()
+
+	users, _ := m.Get("users")
+	orders, _ := m.Get("orders")
+
+	err := m.Reload(context.Background(),
+		Config{User: "user", Host: "127.0.0.1:1", DBName: "users"},
+		Config{User: "user", Host: "127.0.0.1:1", DBName: "orders", MaxOpenConn: 5},
+		Config{User: "user", Host: "127.0.0.1:1", DBName: "billing"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.Names(); !reflect.DeepEqual(got, []string{"billing", "orders", "users"}) {
+		t.Errorf("Names() = %v", got)
+	}
+
+	if db, _ := m.Get("users"); db != users {
+		t.Error("unchanged connection was reopened")
+	}
+
+	db, _ := m.Get("orders")
+	if db == orders {
+		t.Fatal("changed connection was not reopened")
+	}
+
+	if sqlDB, _ := db.DB(); sqlDB.Stats().MaxOpenConnections != 5 {
+		t.Errorf("MaxOpenConnections = %d, want 5", sqlDB.Stats().MaxOpenConnections)
+	}
+
+	m.draining.Wait()
+	if sqlDB, _ := orders.DB(); !poolClosed(sqlDB) {
+		t.Error("replaced pool was not closed")
+	}
+
+	if err = m.Reload(context.Background(), Config{User: "user", Host: "127.0.0.1:1", DBName: "users"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = m.Get("orders"); !errors.Is(err, ErrUnknownConnection) {
+		t.Errorf("expected ErrUnknownConnection for a removed connection, got %v", err)
+	}
+}
+
+func TestManagerReloadKeepsConnectionsOnError(t *testing.T) {
+	m := newTestManager(t, "users")
+
+	users := Config{User: "user", Host: "127.0.0.1:1", DBName: "users"}
+	if err := m.Reload(context.Background(), users, users); err == nil {
+		t.Error("expected an error for duplicate connections")
+	}
+
+	if err := m.Reload(context.Background(), Config{DSN: "not a dsn", Name: "users"}); err == nil {
+		t.Error("expected an error for an invalid DSN")
+	}
+
+	if got := m.Names(); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Errorf("Names() = %v", got)
+	}
+
+	_ = m.Close(context.Background())
+	if err := m.Reload(context.Background(), users); !errors.Is(err, ErrManagerClosed) {
+		t.Errorf("expected ErrManagerClosed, got %v", err)
+	}
+}
+
+func TestManagerWatchFile(t *testing.T) {
+	path := writeConfigFile(t, "mysql.yaml", "connections:\n  - host: 127.0.0.1:1\n    db_name: users\n")
+
+	m := newTestManager(t, "users")
+	defer func() { _ = m.Close(context.Background()) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.WatchFile(ctx, path, 5*time.Millisecond) }()
+
+	content := "connections:\n  - host: 127.0.0.1:1\n    db_name: users\n  - host: 127.0.0.1:1\n    db_name: orders\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(m.Names()) != 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := m.Names(); !reflect.DeepEqual(got, []string{"orders", "users"}) {
+		t.Errorf("Names() = %v after the file changed", got)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("WatchFile() = %v, want context.Canceled", err)
+	}
+}
+
+func TestManagerWatchFileRetriesFailedReload(t *testing.T) {
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	content := "connections:\n  - host: 127.0.0.1:1\n    db_name: users\n  - host: 127.0.0.1:1\n    db_name: orders\n" +
+		"    tls:\n      mode: \"true\"\n      ca_file: " + ca + "\n"
+	path := writeConfigFile(t, "mysql.yaml", content)
+
+	m := newTestManager(t, "users")
+
+	done := make(chan error, 1)
+	go func() { done <- m.WatchFile(context.Background(), path, 5*time.Millisecond) }()
+
+	// The reload fails until the CA file exists, although the configuration file is unchanged
+	time.Sleep(30 * time.Millisecond)
+	if got := m.Names(); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Fatalf("Names() = %v before the CA file exists", got)
+	}
+
+	writeCAFile(t, ca, 1)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(m.Names()) != 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := m.Names(); !reflect.DeepEqual(got, []string{"orders", "users"}) {
+		t.Errorf("Names() = %v, the failed reload was not retried", got)
+	}
+
+	_ = m.Close(context.Background())
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrManagerClosed) {
+			t.Errorf("WatchFile() = %v, want ErrManagerClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("WatchFile did not return after Close")
+	}
+}
+
+// stubConnector is a driver.Connector handing out stubConn connections.
+type stubConnector struct{}
+
+func (stubConnector) Connect(context.Context) (driver.Conn, error) { return stubConn{}, nil }
+func (stubConnector) Driver() driver.Driver                        { return nil }
+
+func TestInUseCountsReplicas(t *testing.T) {
+	db, r := newTestResolver(t)
+
+	replica := sql.OpenDB(stubConnector{})
+	defer func() { _ = replica.Close() }()
+	r.replicas[1].sqlDB = replica
+
+	conn, err := replica.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := inUse(db); got != 1 {
+		t.Errorf("inUse() = %d, want 1 while a replica connection is in use", got)
+	}
+
+	_ = conn.Close()
+	if got := inUse(db); got != 0 {
+		t.Errorf("inUse() = %d, want 0", got)
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	a := Config{User: "app", Password: "old", Host: "127.0.0.1:3306", DBName: "users"}
+	b := a
+	b.Password = "new"
+	b.Hosts = []string{"10.0.0.1:3306"}
+
+	if got := configDiff(a, b); !reflect.DeepEqual(got, []string{"Password", "Hosts"}) {
+		t.Errorf("configDiff() = %v", got)
+	}
+
+	if got := configDiff(a, a); len(got) != 0 {
+		t.Errorf("configDiff() = %v for equal configs", got)
+	}
+}
+
+func TestFileSettingsDiff(t *testing.T) {
+	a := &FileConfig{MaxOpenConn: 80, Connections: []FileConnection{{Host: "h", DBName: "users"}}}
+	b := &FileConfig{MaxOpenConn: 100, Gorm: FileGormConfig{PrepareStmt: true}, Logger: &FileLogConfig{Level: "info"}}
+
+	if got := fileSettingsDiff(a, b); !reflect.DeepEqual(got, []string{"max_open_conn", "gorm", "logger"}) {
+		t.Errorf("fileSettingsDiff() = %v", got)
+	}
+
+	if got := fileSettingsDiff(nil, b); got != nil {
+		t.Errorf("fileSettingsDiff() = %v without a previous file", got)
+	}
+}
@@ -0,0 +1,439 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaPolicy selects the replica that serves a read query.
+type ReplicaPolicy string
+
+const (
+	// ReplicaPolicyRandom picks a random replica for every read.
+	ReplicaPolicyRandom ReplicaPolicy = "random"
+	// ReplicaPolicyRoundRobin cycles through the replicas in order.
+	ReplicaPolicyRoundRobin ReplicaPolicy = "round_robin"
+	// ReplicaPolicyLeastInUse picks the replica with the fewest in-use connections
+	// according to sql.DBStats.
+	ReplicaPolicyLeastInUse ReplicaPolicy = "least_in_use"
+)
+
+const (
+	// resolverPluginName is the name under which the read/write splitting plugin is registered.
+	resolverPluginName = "mysql:resolver"
+	// usePrimaryKey is the statement setting that forces a query to the primary.
+	usePrimaryKey = "mysql:use_primary"
+)
+
+// closer is implemented by plugins that own resources which must be released together
+// with the connection they are registered on.
+type closer interface {
+	close() error
+}
+
+// UsePrimary returns a session that sends every query, including reads, to the primary.
+// Use it when a read must observe a write that was just made outside of a transaction.
+//
+// Parameters:
+//   - db: The gorm.DB to derive the session from.
+//
+// Returns:
+//   - A new gorm.DB session bound to the primary.
+//
+// Example:
+//
+//	var order Order
+//	err := UsePrimary(db).First(&order, id).Error
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Set(usePrimaryKey, true).Session(&gorm.Session{})
+}
+
+// replica is a read replica connection pool.
+type replica struct {
+	name    string        // Replica name used in log messages
+	host    string        // Replica host used in log messages
+	db      *gorm.DB      // The gorm.DB owning the replica pool
+	sqlDB   *sql.DB       // The replica pool, used for statistics
+	pool    gorm.ConnPool // The pool statements are routed to
+	ejected atomic.Bool   // Whether the replica is currently excluded from routing
+
+	legacyStatus atomic.Bool // Whether the server only supports SHOW SLAVE STATUS
+}
+
+// resolver is a GORM plugin that routes read queries to replicas and everything else,
+// including transactions, to the primary.
+type resolver struct {
+	primary  gorm.ConnPool
+	replicas []*replica
+	policy   ReplicaPolicy
+	next     atomic.Uint64
+	checker  *lagChecker
+
+	consistency     ConsistencyMode
+	gtidWaitTimeout time.Duration
+	stickyWindow    time.Duration
+	log             gormlogger.Interface
+}
+
+// validate checks that the policy is empty or one of the defined replica policies.
+//
+// Returns:
+//   - An error naming the unknown policy, or nil.
+func (p ReplicaPolicy) validate() error {
+	switch p {
+	case "", ReplicaPolicyRandom, ReplicaPolicyRoundRobin, ReplicaPolicyLeastInUse:
+		return nil
+	default:
+		return fmt.Errorf("unknown replica policy %q", p)
+	}
+}
+
+// Name returns the plugin name.
+func (r *resolver) Name() string {
+	return resolverPluginName
+}
+
+// Initialize registers the routing callbacks on the primary connection.
+//
+// Parameters:
+//   - db: The primary gorm.DB.
+//
+// Returns:
+//   - An error if a callback cannot be registered.
+func (r *resolver) Initialize(db *gorm.DB) error {
+	r.primary = db.ConnPool
+
+	if db.Config.PrepareStmt {
+		for _, rep := range r.replicas {
+			rep.pool = gorm.NewPreparedStmtDB(rep.sqlDB)
+		}
+	}
+
+	return errors.Join(
+		db.Callback().Query().Before("gorm:query").Register(resolverPluginName, r.routeRead),
+		db.Callback().Row().Before("gorm:row").Register(resolverPluginName, r.routeRead),
+		db.Callback().Raw().Before("gorm:raw").Register(resolverPluginName, r.routeRead),
+		db.Callback().Create().Before("gorm:create").Register(resolverPluginName, r.routeWrite),
+		db.Callback().Update().Before("gorm:update").Register(resolverPluginName, r.routeWrite),
+		db.Callback().Delete().Before("gorm:delete").Register(resolverPluginName, r.routeWrite),
+	)
+}
+
+// routeRead sends read statements to a replica and everything else to the primary.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+func (r *resolver) routeRead(db *gorm.DB) {
+	if query := db.Statement.SQL.String(); query != "" && !isReadQuery(query) {
+		r.wrote(db)
+	}
+
+	if !r.routable(db) {
+		return
+	}
+
+	if !r.readable(db) {
+		db.Statement.ConnPool = r.primary
+		return
+	}
+
+	rep := r.pick()
+	if rep == nil || !r.consistent(db, rep) {
+		db.Statement.ConnPool = r.primary
+		return
+	}
+
+	db.Statement.ConnPool = rep.pool
+}
+
+// routeWrite sends write statements to the primary.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+func (r *resolver) routeWrite(db *gorm.DB) {
+	r.wrote(db)
+
+	if r.routable(db) {
+		db.Statement.ConnPool = r.primary
+	}
+}
+
+// readable reports whether the statement may be served by a replica.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+//
+// Returns:
+//   - true if the statement is a plain read that is not pinned to the primary.
+func (r *resolver) readable(db *gorm.DB) bool {
+	if db.Error != nil {
+		return false
+	}
+
+	if v, ok := db.Get(usePrimaryKey); ok && v == true {
+		return false
+	}
+
+	// Locking reads must see and lock the rows on the primary
+	if _, ok := db.Statement.Clauses["FOR"]; ok {
+		return false
+	}
+
+	query := db.Statement.SQL.String()
+	if query == "" {
+		// The statement is built by GORM from the model, which is a SELECT for queries and rows
+		return true
+	}
+
+	return isReadQuery(query)
+}
+
+// pick selects a replica according to the policy.
+//
+// Returns:
+//   - The selected replica, or nil if no replica is available.
+func (r *resolver) pick() *replica {
+	available := make([]*replica, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		if !rep.ejected.Load() {
+			available = append(available, rep)
+		}
+	}
+
+	if len(available) == 0 {
+		return nil
+	}
+
+	switch r.policy {
+	case ReplicaPolicyRoundRobin:
+		return available[(r.next.Add(1)-1)%uint64(len(available))]
+	case ReplicaPolicyLeastInUse:
+		best := available[0]
+		bestInUse := best.sqlDB.Stats().InUse
+		for _, rep := range available[1:] {
+			if inUse := rep.sqlDB.Stats().InUse; inUse < bestInUse {
+				best, bestInUse = rep, inUse
+			}
+		}
+
+		return best
+	default:
+		return available[rand.IntN(len(available))]
+	}
+}
+
+// close stops the lag checker and closes every replica pool.
+//
+// Returns:
+//   - The joined errors of every replica that failed to close.
+func (r *resolver) close() error {
+	r.checker.Stop()
+
+	var errs []error
+	for _, rep := range r.replicas {
+		if err := closeDB(rep.db); err != nil {
+			errs = append(errs, fmt.Errorf("close replica %q: %w", rep.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// routable reports whether the statement runs on the primary pool and may be rerouted.
+// Statements in a transaction, on a connection pinned with gorm.DB.Connection or in a
+// session wrapping the pool, such as a PrepareStmt session, must stay on their connection.
+//
+// Parameters:
+//   - db: The gorm.DB of the statement being executed.
+//
+// Returns:
+//   - true if the statement's connection pool is the primary pool.
+func (r *resolver) routable(db *gorm.DB) bool {
+	return db.Statement.ConnPool == r.primary
+}
+
+// isReadQuery reports whether a raw SQL statement is a non-locking SELECT.
+//
+// Parameters:
+//   - query: The SQL statement.
+//
+// Returns:
+//   - true if the statement can be served by a replica.
+func isReadQuery(query string) bool {
+	q := strings.ToUpper(strings.TrimLeft(strings.TrimSpace(query), "("))
+	if !strings.HasPrefix(q, "SELECT") {
+		return false
+	}
+
+	return !strings.Contains(q, " FOR UPDATE") && !strings.Contains(q, " FOR SHARE") && !strings.Contains(q, " LOCK IN SHARE MODE")
+}
+
+// openReplicas opens the replicas of a configuration and registers the read/write
+// splitting plugin on the primary.
+//
+// Parameters:
+//   - ctx: The context bounding the connection attempts.
+//   - db: The primary gorm.DB.
+//   - c: A pointer to the resolved primary configuration.
+//   - opt: A pointer to the option struct holding the global settings.
+//
+// Returns:
+//   - An error if the replica policy or consistency mode is unknown, a replica cannot be
+//     opened or the plugin cannot be registered. Replicas opened before the error are
+//     closed again.
+func openReplicas(ctx context.Context, db *gorm.DB, c *Config, opt *option) error {
+	if err := errors.Join(c.ReplicaPolicy.validate(), c.Consistency.validate()); err != nil {
+		return err
+	}
+
+	r := &resolver{
+		policy:          c.ReplicaPolicy,
+		consistency:     c.Consistency,
+		gtidWaitTimeout: c.GTIDWaitTimeout,
+		stickyWindow:    c.StickyWindow,
+		log:             c.logger(),
+	}
+	if r.gtidWaitTimeout <= 0 {
+		r.gtidWaitTimeout = defaultGTIDWaitTimeout
+	}
+	if r.stickyWindow <= 0 {
+		r.stickyWindow = defaultStickyWindow
+	}
+
+	// Replicas are only reached through the primary, whose plugin reports their queries
+	ro := *opt
+	ro.metrics = nil
+
+	for i := range c.Replicas {
+		rc := c.Replicas[i].inherit(c, i)
+
+		rdb, err := newConnect(ctx, &rc, &ro)
+		if err != nil {
+			_ = r.close()
+			return fmt.Errorf("connect replica %q (%s): %w", rc.key(), rc.host(), err)
+		}
+
+		sqlDB, err := rdb.DB()
+		if err != nil {
+			_ = closeDB(rdb)
+			_ = r.close()
+			return err
+		}
+
+		r.replicas = append(r.replicas, &replica{name: rc.key(), host: rc.host(), db: rdb, sqlDB: sqlDB, pool: sqlDB})
+	}
+
+	if err := db.Use(r); err != nil {
+		_ = r.close()
+		return err
+	}
+
+	// Eject lagging replicas from routing, if enabled
+	r.checker = startLagChecker(r.replicas, c.MaxReplicaLag, c.ReplicaLagCheckInterval, r.log)
+
+	return nil
+}
+
+// inherit returns a copy of the replica configuration in which every unset field is taken
+// from the primary configuration.
+//
+// Parameters:
+//   - primary: A pointer to the resolved primary configuration.
+//   - index: The position of the replica, used to name it.
+//
+// Returns:
+//   - The resolved replica configuration.
+func (c *Config) inherit(primary *Config, index int) Config {
+	rc := *c
+	rc.Replicas = nil
+
+	if rc.Name == "" {
+		rc.Name = fmt.Sprintf("%s-replica-%d", primary.key(), index+1)
+	}
+
+	if rc.DSN != "" {
+		return rc
+	}
+
+	inheritString := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+
+	inheritString(&rc.User, primary.User)
+	inheritString(&rc.Password, primary.Password)
+
+	if rc.Credentials == nil && c.Password == "" {
+		rc.Credentials = primary.Credentials
+	}
+	inheritString(&rc.Network, primary.Network)
+	inheritString(&rc.DBName, primary.DBName)
+	inheritString(&rc.Charset, primary.Charset)
+	inheritString(&rc.Collation, primary.Collation)
+
+	if rc.Port == 0 {
+		rc.Port = primary.Port
+	}
+
+	if rc.Loc == nil {
+		rc.Loc = primary.Loc
+	}
+
+	if rc.Timeout == 0 {
+		rc.Timeout = primary.Timeout
+	}
+
+	if rc.ReadTimeout == 0 {
+		rc.ReadTimeout = primary.ReadTimeout
+	}
+
+	if rc.WriteTimeout == 0 {
+		rc.WriteTimeout = primary.WriteTimeout
+	}
+
+	if rc.MaxAllowedPacket == 0 {
+		rc.MaxAllowedPacket = primary.MaxAllowedPacket
+	}
+
+	if rc.Params == nil {
+		rc.Params = primary.Params
+	}
+
+	if rc.TLS == nil {
+		rc.TLS = primary.TLS
+	}
+
+	if rc.MaxIdleConn <= 0 {
+		rc.MaxIdleConn = primary.MaxIdleConn
+	}
+
+	if rc.MaxOpenConn <= 0 {
+		rc.MaxOpenConn = primary.MaxOpenConn
+	}
+
+	if rc.ConnMaxLifetime <= 0 {
+		rc.ConnMaxLifetime = primary.ConnMaxLifetime
+	}
+
+	if rc.ConnMaxIdleTime <= 0 {
+		rc.ConnMaxIdleTime = primary.ConnMaxIdleTime
+	}
+
+	if rc.GormConfig == nil && primary.GormConfig != nil {
+		// Plugins belong to the primary, replicas are only reached through it
+		gormCfg := *primary.GormConfig
+		gormCfg.Plugins = nil
+		rc.GormConfig = &gormCfg
+	}
+
+	return rc
+}
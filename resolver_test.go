@@ -0,0 +1,129 @@
+package mysql
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"testing"
+)
+
+func newTestResolver(t *testing.T) (*gorm.DB, *resolver) {
+	t.Helper()
+
+	cfg := Config{
+		Name:          "shop",
+		User:          "user",
+		Host:          "127.0.0.1:1",
+		DBName:        "shop",
+		Replicas:      []Config{{Host: "127.0.0.2:1"}, {Host: "127.0.0.3:1"}},
+		ReplicaPolicy: ReplicaPolicyRoundRobin,
+	}
+
+	db, err := New(WithConfigs(cfg), WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = closeDB(db) })
+
+	r, ok := db.Config.Plugins[resolverPluginName].(*resolver)
+	if !ok {
+		t.Fatal("resolver plugin is not registered")
+	}
+
+	return db, r
+}
+
+func TestResolverRouting(t *testing.T) {
+	db, r := newTestResolver(t)
+	dry := db.Session(&gorm.Session{DryRun: true})
+
+	if len(r.replicas) != 2 || r.replicas[0].name != "shop-replica-1" || r.replicas[1].host != "127.0.0.3:1" {
+		t.Fatalf("unexpected replicas: %+v", r.replicas)
+	}
+
+	var products []Product
+	if got := dry.Find(&products).Statement.ConnPool; got != r.replicas[0].pool {
+		t.Error("first read was not routed to the first replica")
+	}
+
+	if got := dry.Find(&products).Statement.ConnPool; got != r.replicas[1].pool {
+		t.Error("second read was not routed to the second replica")
+	}
+
+	tests := map[string]*gorm.DB{
+		"create":      dry.Create(&Product{Code: "D42"}),
+		"raw write":   dry.Exec("UPDATE products SET price = 1"),
+		"use primary": UsePrimary(dry).Find(&products),
+		"locking":     dry.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&products),
+	}
+
+	for name, tx := range tests {
+		if tx.Statement.ConnPool != r.primary {
+			t.Errorf("%s was not routed to the primary", name)
+		}
+	}
+
+	r.replicas[0].ejected.Store(true)
+	r.replicas[1].ejected.Store(true)
+	if got := dry.Find(&products).Statement.ConnPool; got != r.primary {
+		t.Error("read was not routed to the primary when every replica is ejected")
+	}
+}
+
+// pinnedConn stands for the *sql.Conn that gorm.DB.Connection pins a session to.
+type pinnedConn struct {
+	gorm.ConnPool
+}
+
+func TestResolverKeepsPinnedConnection(t *testing.T) {
+	db, r := newTestResolver(t)
+	dry := db.Session(&gorm.Session{DryRun: true})
+
+	conn := &pinnedConn{ConnPool: r.primary}
+	pinned := dry.Session(&gorm.Session{})
+	pinned.Statement.ConnPool = conn
+
+	var products []Product
+	if got := pinned.Find(&products).Statement.ConnPool; got != conn {
+		t.Error("read on a pinned connection was rerouted")
+	}
+
+	if got := pinned.Exec("SET @a = 1").Statement.ConnPool; got != conn {
+		t.Error("raw statement on a pinned connection was rerouted")
+	}
+
+	if got := pinned.Create(&Product{Code: "D42"}).Statement.ConnPool; got != conn {
+		t.Error("write on a pinned connection was rerouted")
+	}
+
+	prepared := dry.Session(&gorm.Session{PrepareStmt: true})
+	if _, ok := prepared.Find(&products).Statement.ConnPool.(*gorm.PreparedStmtDB); !ok {
+		t.Error("read in a PrepareStmt session lost its prepared statement pool")
+	}
+}
+
+func TestResolverUnknownPolicy(t *testing.T) {
+	cfg := Config{User: "user", Host: "127.0.0.1:1", DBName: "shop",
+		Replicas: []Config{{Host: "127.0.0.2:1"}}, ReplicaPolicy: "least-in-use"}
+
+	if _, err := New(WithConfigs(cfg), WithLazyConnect()); err == nil || !strings.Contains(err.Error(), `"least-in-use"`) {
+		t.Errorf("expected an unknown replica policy error, got %v", err)
+	}
+}
+
+func TestIsReadQuery(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT * FROM products":                       true,
+		"  (select id from products) union (select 1)": true,
+		"SELECT * FROM products FOR UPDATE":            false,
+		"select * from products lock in share mode":    false,
+		"UPDATE products SET price = 1":                false,
+		"INSERT INTO products VALUES (1)":              false,
+	}
+
+	for query, want := range tests {
+		if got := isReadQuery(query); got != want {
+			t.Errorf("isReadQuery(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
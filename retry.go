@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"context"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"math/rand/v2"
+	"time"
+)
+
+// retryPolicy controls how often and how fast a failed connection attempt is retried.
+type retryPolicy struct {
+	maxAttempts    int           // Maximum number of attempts, including the first one
+	initialBackoff time.Duration // Delay before the second attempt
+	maxBackoff     time.Duration // Upper bound of the delay between attempts
+}
+
+// WithConnectRetry returns an Option that retries failed connection attempts during startup
+// with exponential backoff and jitter.
+//
+// The delay before attempt n+1 is initialBackoff*2^(n-1), capped at maxBackoff, with a random
+// jitter of up to half of the delay. Every failed attempt is logged through the configured
+// GORM logger. Use NewContext or NewMultiContext to bound or cancel the whole startup.
+//
+// Parameters:
+//   - maxAttempts: The maximum number of attempts, including the first one.
+//   - initialBackoff: The delay before the second attempt.
+//   - maxBackoff: The upper bound of the delay between attempts.
+//
+// Returns:
+//   - An Option function that sets the retry policy when applied.
+//
+// Example:
+//
+//	db, err := New(WithConfigs(cfg), WithConnectRetry(10, 500*time.Millisecond, 10*time.Second))
+func WithConnectRetry(maxAttempts int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(o *option) {
+		o.retry = retryPolicy{
+			maxAttempts:    maxAttempts,
+			initialBackoff: initialBackoff,
+			maxBackoff:     maxBackoff,
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt.
+//
+// Parameters:
+//   - attempt: The number of the attempt that just failed, starting at 1.
+//
+// Returns:
+//   - The delay including jitter.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.initialBackoff
+	for i := 1; i < attempt && (p.maxBackoff <= 0 || d < p.maxBackoff); i++ {
+		d *= 2
+	}
+
+	if p.maxBackoff > 0 && d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	// Subtract up to half of the delay so that restarting services do not retry in lockstep
+	return d - time.Duration(rand.Int64N(int64(d)/2+1))
+}
+
+// do calls open until it succeeds, the attempts are exhausted or ctx is done.
+//
+// Parameters:
+//   - ctx: The context bounding all attempts.
+//   - log: The logger used to report failed attempts.
+//   - name: The connection name used in log messages.
+//   - host: The connection host used in log messages.
+//   - open: The function opening the connection.
+//
+// Returns:
+//   - The gorm.DB returned by the first successful attempt.
+//   - The error of the last attempt, or the context error.
+func (p retryPolicy) do(ctx context.Context, log gormlogger.Interface, name, host string, open func() (*gorm.DB, error)) (*gorm.DB, error) {
+	maxAttempts := p.maxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		db, err := open()
+		if err == nil || attempt >= maxAttempts {
+			return db, err
+		}
+
+		delay := p.backoff(attempt)
+		log.Warn(ctx, "connect %q (%s) attempt %d/%d failed: %v, retrying in %s",
+			name, host, attempt, maxAttempts, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
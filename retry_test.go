@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := retryPolicy{maxAttempts: 10, initialBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+
+	for _, tt := range tests {
+		got := p.backoff(tt.attempt)
+		if got < tt.max/2 || got > tt.max {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.max/2, tt.max)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	p := retryPolicy{maxAttempts: 3, initialBackoff: time.Millisecond, maxBackoff: time.Millisecond}
+	errDial := errors.New("dial failed")
+
+	attempts := 0
+	_, err := p.do(context.Background(), gormlogger.Discard, "main", "127.0.0.1:3306", func() (*gorm.DB, error) {
+		attempts++
+		return nil, errDial
+	})
+	if !errors.Is(err, errDial) || attempts != 3 {
+		t.Errorf("do() error = %v after %d attempts, want %v after 3", err, attempts, errDial)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.initialBackoff, p.maxBackoff = time.Hour, time.Hour
+	attempts = 0
+	_, err = p.do(ctx, gormlogger.Discard, "main", "127.0.0.1:3306", func() (*gorm.DB, error) {
+		attempts++
+		cancel()
+		return nil, errDial
+	})
+	if !errors.Is(err, context.Canceled) || attempts != 1 {
+		t.Errorf("do() error = %v after %d attempts, want context.Canceled after 1", err, attempts)
+	}
+}
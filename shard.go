@@ -0,0 +1,459 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// defaultVirtualNodes is the default number of points each shard owns on the hash ring
+// of a ConsistentHashSharder.
+const defaultVirtualNodes = 100
+
+// ErrUnknownShard is returned when a shard key does not map to any shard.
+var ErrUnknownShard = errors.New("shard key does not map to any shard")
+
+// Sharder maps shard keys to the names of the connections holding them.
+//
+// Shard keys are integers, strings or byte slices. Integer keys of every width map to the
+// same shard for the same value.
+type Sharder interface {
+	// Shard returns the name of the shard holding the key.
+	Shard(key any) (string, error)
+	// Shards returns the names of every shard the sharder can return.
+	Shards() []string
+}
+
+// ShardRouter routes shard keys to the connections of a sharded database.
+type ShardRouter struct {
+	dbs     map[string]*gorm.DB
+	sharder Sharder
+}
+
+// NewShardRouter returns a router over the given connections, such as the map returned
+// by NewMulti.
+//
+// Parameters:
+//   - dbs: The connections keyed by name.
+//   - sharder: The Sharder mapping keys to connection names.
+//
+// Returns:
+//   - A pointer to the ShardRouter.
+//   - An error if the sharder refers to a connection that is not in dbs.
+//
+// Example:
+//
+//	dbs, err := NewMulti(WithConfigs(users0, users1, users2))
+//	router, err := NewShardRouter(dbs, NewModuloSharder("users_0", "users_1", "users_2"))
+//	db, err := router.DB(userID)
+func NewShardRouter(dbs map[string]*gorm.DB, sharder Sharder) (*ShardRouter, error) {
+	if sharder == nil {
+		return nil, errors.New("shard router requires a sharder")
+	}
+
+	shards := sharder.Shards()
+	if len(shards) == 0 {
+		return nil, errors.New("shard router requires at least one shard")
+	}
+
+	routed := make(map[string]*gorm.DB, len(shards))
+	for _, name := range shards {
+		db, ok := dbs[name]
+		if !ok {
+			return nil, fmt.Errorf("shard %q: %w", name, ErrUnknownConnection)
+		}
+
+		routed[name] = db
+	}
+
+	return &ShardRouter{dbs: routed, sharder: sharder}, nil
+}
+
+// DB returns the connection of the shard holding the key.
+//
+// Parameters:
+//   - key: The shard key.
+//
+// Returns:
+//   - The gorm.DB of the shard.
+//   - An error if the key does not map to a shard.
+//
+// Example:
+//
+//	db, err := router.DB(userID)
+//	if err != nil {
+//	    return err
+//	}
+//	err = db.First(&user, userID).Error
+func (r *ShardRouter) DB(key any) (*gorm.DB, error) {
+	name, err := r.sharder.Shard(key)
+	if err != nil {
+		return nil, err
+	}
+
+	db, ok := r.dbs[name]
+	if !ok {
+		return nil, fmt.Errorf("shard %q: %w", name, ErrUnknownConnection)
+	}
+
+	return db, nil
+}
+
+// Shards returns the names of every shard in sorted order.
+//
+// Returns:
+//   - The shard names.
+func (r *ShardRouter) Shards() []string {
+	names := make([]string, 0, len(r.dbs))
+	for name := range r.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// FanOut runs query on every shard concurrently and merges the results in shard name
+// order. The query receives the shard connection bound to ctx.
+//
+// Parameters:
+//   - ctx: The context passed to every shard query.
+//   - r: The shard router.
+//   - query: The function querying a single shard.
+//
+// Returns:
+//   - The concatenated results of every shard.
+//   - The joined errors of every shard that failed, prefixed with the shard name. The
+//     results of the successful shards are returned as well.
+//
+// Example:
+//
+//	users, err := FanOut(ctx, router, func(ctx context.Context, db *gorm.DB) ([]User, error) {
+//	    var users []User
+//	    err := db.Where("created_at > ?", since).Find(&users).Error
+//	    return users, err
+//	})
+func FanOut[T any](ctx context.Context, r *ShardRouter, query func(ctx context.Context, db *gorm.DB) ([]T, error)) ([]T, error) {
+	names := r.Shards()
+	results := make([][]T, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			rows, err := query(ctx, r.dbs[name].WithContext(ctx))
+			if err != nil {
+				errs[i] = fmt.Errorf("shard %q: %w", name, err)
+			}
+			results[i] = rows
+		}(i, name)
+	}
+	wg.Wait()
+
+	var merged []T
+	for _, rows := range results {
+		merged = append(merged, rows...)
+	}
+
+	return merged, errors.Join(errs...)
+}
+
+// ModuloSharder maps integer keys to shards by their remainder and other keys by the
+// remainder of their hash.
+type ModuloSharder struct {
+	names []string
+}
+
+// NewModuloSharder returns a ModuloSharder over the given shards. The order of the names
+// is part of the mapping and must not change once data has been written.
+//
+// Parameters:
+//   - names: The shard names; key k maps to names[k % len(names)].
+//
+// Returns:
+//   - A pointer to the ModuloSharder.
+func NewModuloSharder(names ...string) *ModuloSharder {
+	return &ModuloSharder{names: names}
+}
+
+// Shard returns the name of the shard holding the key.
+func (s *ModuloSharder) Shard(key any) (string, error) {
+	if len(s.names) == 0 {
+		return "", ErrUnknownShard
+	}
+
+	n := uint64(len(s.names))
+	if u, ok := shardKeyUint(key); ok {
+		return s.names[u%n], nil
+	}
+
+	b, err := shardKeyBytes(key)
+	if err != nil {
+		return "", err
+	}
+
+	return s.names[hashShardKey(b)%n], nil
+}
+
+// Shards returns the names of every shard.
+func (s *ModuloSharder) Shards() []string {
+	return s.names
+}
+
+// ConsistentHashSharder maps keys to shards on a hash ring, so that adding or removing a
+// shard only moves the keys of that shard.
+type ConsistentHashSharder struct {
+	names  []string
+	points []uint64          // Sorted ring positions
+	owners map[uint64]string // Shard owning each ring position
+}
+
+// NewConsistentHashSharder returns a ConsistentHashSharder over the given shards.
+//
+// Parameters:
+//   - virtualNodes: The number of ring positions per shard, defaults to 100 when not
+//     greater than 0. More positions spread keys more evenly.
+//   - names: The shard names.
+//
+// Returns:
+//   - A pointer to the ConsistentHashSharder.
+func NewConsistentHashSharder(virtualNodes int, names ...string) *ConsistentHashSharder {
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+
+	s := &ConsistentHashSharder{names: names, owners: make(map[uint64]string, len(names)*virtualNodes)}
+	for _, name := range names {
+		for i := 0; i < virtualNodes; i++ {
+			point := hashShardKey([]byte(name + "#" + strconv.Itoa(i)))
+			if _, taken := s.owners[point]; taken {
+				continue
+			}
+
+			s.owners[point] = name
+			s.points = append(s.points, point)
+		}
+	}
+	sort.Slice(s.points, func(i, j int) bool { return s.points[i] < s.points[j] })
+
+	return s
+}
+
+// Shard returns the name of the shard holding the key.
+func (s *ConsistentHashSharder) Shard(key any) (string, error) {
+	if len(s.points) == 0 {
+		return "", ErrUnknownShard
+	}
+
+	b, err := shardKeyBytes(key)
+	if err != nil {
+		return "", err
+	}
+
+	h := hashShardKey(b)
+	i := sort.Search(len(s.points), func(i int) bool { return s.points[i] >= h })
+	if i == len(s.points) {
+		i = 0
+	}
+
+	return s.owners[s.points[i]], nil
+}
+
+// Shards returns the names of every shard.
+func (s *ConsistentHashSharder) Shards() []string {
+	return s.names
+}
+
+// ShardRange assigns the integer keys in [From, To) to a shard.
+type ShardRange struct {
+	From int64  // First key of the range
+	To   int64  // First key after the range; math.MaxInt64 is treated as inclusive
+	Name string // Shard name
+}
+
+// RangeSharder maps integer keys to shards with a range table.
+type RangeSharder struct {
+	ranges []ShardRange
+}
+
+// NewRangeSharder returns a RangeSharder over the given ranges.
+//
+// Parameters:
+//   - ranges: The ranges, in any order.
+//
+// Returns:
+//   - A pointer to the RangeSharder.
+//   - An error if a range is empty or ranges overlap.
+//
+// Example:
+//
+//	sharder, err := NewRangeSharder(
+//	    ShardRange{From: 0, To: 1_000_000, Name: "users_0"},
+//	    ShardRange{From: 1_000_000, To: math.MaxInt64, Name: "users_1"},
+//	)
+func NewRangeSharder(ranges ...ShardRange) (*RangeSharder, error) {
+	sorted := append([]ShardRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+
+	for i, rg := range sorted {
+		if rg.From >= rg.To {
+			return nil, fmt.Errorf("shard range %q is empty: [%d, %d)", rg.Name, rg.From, rg.To)
+		}
+
+		if i > 0 && rg.From < sorted[i-1].To {
+			return nil, fmt.Errorf("shard ranges %q and %q overlap", sorted[i-1].Name, rg.Name)
+		}
+	}
+
+	return &RangeSharder{ranges: sorted}, nil
+}
+
+// Shard returns the name of the shard whose range holds the key.
+func (s *RangeSharder) Shard(key any) (string, error) {
+	v, ok := shardKeyInt(key)
+	if !ok {
+		return "", fmt.Errorf("range sharding requires an integer key, got %T", key)
+	}
+
+	i := sort.Search(len(s.ranges), func(i int) bool { return s.ranges[i].To > v })
+	if i < len(s.ranges) && s.ranges[i].From <= v {
+		return s.ranges[i].Name, nil
+	}
+
+	if v == math.MaxInt64 && len(s.ranges) > 0 && s.ranges[len(s.ranges)-1].To == math.MaxInt64 {
+		return s.ranges[len(s.ranges)-1].Name, nil
+	}
+
+	return "", fmt.Errorf("%w: %d", ErrUnknownShard, v)
+}
+
+// Shards returns the names of every shard, without duplicates.
+func (s *RangeSharder) Shards() []string {
+	seen := make(map[string]bool, len(s.ranges))
+	var names []string
+	for _, rg := range s.ranges {
+		if !seen[rg.Name] {
+			seen[rg.Name] = true
+			names = append(names, rg.Name)
+		}
+	}
+
+	return names
+}
+
+// shardKeyInt converts an integer shard key to int64.
+//
+// Parameters:
+//   - key: The shard key.
+//
+// Returns:
+//   - The key value.
+//   - false if the key is not an integer or does not fit in an int64.
+func shardKeyInt(key any) (int64, bool) {
+	switch v := key.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	default:
+		return 0, false
+	}
+}
+
+// shardKeyUint returns the magnitude of an integer shard key, so that negative keys map
+// to the same shard as their absolute value.
+//
+// Parameters:
+//   - key: The shard key.
+//
+// Returns:
+//   - The absolute value of the key.
+//   - false if the key is not an integer.
+func shardKeyUint(key any) (uint64, bool) {
+	switch v := key.(type) {
+	case uint:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	}
+
+	v, ok := shardKeyInt(key)
+	if !ok {
+		return 0, false
+	}
+
+	if v < 0 {
+		return uint64(-(v + 1)) + 1, true
+	}
+
+	return uint64(v), true
+}
+
+// shardKeyBytes returns the bytes hashed for a shard key. Integer keys are hashed by
+// their decimal representation.
+//
+// Parameters:
+//   - key: The shard key.
+//
+// Returns:
+//   - The bytes of the key.
+//   - An error if the key type is not supported.
+func shardKeyBytes(key any) ([]byte, error) {
+	switch v := key.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+
+	if v, ok := shardKeyInt(key); ok {
+		return strconv.AppendInt(nil, v, 10), nil
+	}
+
+	if v, ok := shardKeyUint(key); ok {
+		return strconv.AppendUint(nil, v, 10), nil
+	}
+
+	return nil, fmt.Errorf("unsupported shard key type %T", key)
+}
+
+// hashShardKey returns the 64-bit hash of a shard key: FNV-1a followed by the MurmurHash3
+// finalizer, which spreads similar keys such as "user-1" and "user-2" across the ring.
+func hashShardKey(b []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
@@ -0,0 +1,144 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestModuloSharder(t *testing.T) {
+	s := NewModuloSharder("users_0", "users_1", "users_2")
+
+	tests := map[any]string{
+		0:          "users_0",
+		int64(4):   "users_1",
+		uint32(5):  "users_2",
+		-4:         "users_1",
+		uint64(11): "users_2",
+	}
+
+	for key, want := range tests {
+		if got, err := s.Shard(key); err != nil || got != want {
+			t.Errorf("Shard(%v) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+
+	a, _ := s.Shard("alice")
+	b, _ := s.Shard([]byte("alice"))
+	if a != b {
+		t.Errorf("string and byte keys map to different shards: %q, %q", a, b)
+	}
+
+	if _, err := s.Shard(1.5); err == nil {
+		t.Error("expected an error for an unsupported key type")
+	}
+}
+
+func TestConsistentHashSharder(t *testing.T) {
+	before := NewConsistentHashSharder(0, "a", "b", "c", "d")
+	after := NewConsistentHashSharder(0, "a", "b", "c")
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		old, err := before.Shard(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[old]++
+
+		// Removing shard d must only move the keys that were on d
+		if old != "d" {
+			if got, _ := after.Shard(key); got != old {
+				t.Fatalf("key %s moved from %s to %s", key, old, got)
+			}
+		}
+	}
+
+	for _, name := range before.Shards() {
+		if counts[name] < 1500 || counts[name] > 3500 {
+			t.Errorf("shard %s holds %d of 10000 keys", name, counts[name])
+		}
+	}
+}
+
+func TestRangeSharder(t *testing.T) {
+	s, err := NewRangeSharder(
+		ShardRange{From: 1000, To: math.MaxInt64, Name: "users_1"},
+		ShardRange{From: 0, To: 1000, Name: "users_0"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[int64]string{0: "users_0", 999: "users_0", 1000: "users_1", math.MaxInt64: "users_1"}
+	for key, want := range tests {
+		if got, err := s.Shard(key); err != nil || got != want {
+			t.Errorf("Shard(%d) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+
+	if _, err = s.Shard(-1); !errors.Is(err, ErrUnknownShard) {
+		t.Errorf("Shard(-1) error = %v, want ErrUnknownShard", err)
+	}
+
+	if _, err = s.Shard("alice"); err == nil {
+		t.Error("expected an error for a string key")
+	}
+
+	if !reflect.DeepEqual(s.Shards(), []string{"users_0", "users_1"}) {
+		t.Errorf("Shards() = %v", s.Shards())
+	}
+
+	if _, err = NewRangeSharder(ShardRange{From: 0, To: 10, Name: "a"}, ShardRange{From: 5, To: 20, Name: "b"}); err == nil {
+		t.Error("expected an error for overlapping ranges")
+	}
+}
+
+func TestShardRouter(t *testing.T) {
+	m := newTestManager(t, "users_0", "users_1")
+	defer func() { _ = m.Close(context.Background()) }()
+
+	_, dbs, err := m.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewShardRouter(dbs, NewModuloSharder("users_0", "users_2")); !errors.Is(err, ErrUnknownConnection) {
+		t.Errorf("expected ErrUnknownConnection, got %v", err)
+	}
+
+	router, err := NewShardRouter(dbs, NewModuloSharder("users_0", "users_1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db, err := router.DB(3); err != nil || db != dbs["users_1"] {
+		t.Errorf("DB(3) = %p, %v; want users_1", db, err)
+	}
+
+	names, err := FanOut(context.Background(), router, func(ctx context.Context, db *gorm.DB) ([]string, error) {
+		for name, shard := range dbs {
+			if db.ConnPool == shard.ConnPool {
+				if name == "users_1" {
+					return []string{name}, errors.New("timeout")
+				}
+				return []string{name}, nil
+			}
+		}
+		return nil, errors.New("unknown shard")
+	})
+
+	if !reflect.DeepEqual(names, []string{"users_0", "users_1"}) {
+		t.Errorf("FanOut() = %v", names)
+	}
+
+	if err == nil || !strings.Contains(err.Error(), `shard "users_1": timeout`) {
+		t.Errorf("FanOut() error = %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"database/sql"
+	"gorm.io/gorm"
+	"sort"
+)
+
+// PoolStats holds the statistics of a connection pool.
+type PoolStats struct {
+	Connection string      // Connection name, see Config.Name; replicas have their own name
+	Host       string      // Host of the pool, see Config.Host
+	Stats      sql.DBStats // Statistics of the pool at the time of the call
+}
+
+// StatsRegistry receives the connection pools of New, NewMulti and NewManager calls so
+// that their statistics can be exported, for example to Prometheus by the mysqlprom package.
+type StatsRegistry interface {
+	// RegisterPools is called once per New, NewMulti or NewManager call. The pools function
+	// returns the current statistics of every connection and of its read replicas each
+	// time it is called; for a Manager it follows Reload and returns nothing once the
+	// Manager is closed.
+	RegisterPools(pools func() []PoolStats) error
+}
+
+// WithStatsRegistry returns an Option that registers the pools of every connection, and of
+// its read replicas, with the registry.
+//
+// Parameters:
+//   - reg: The registry receiving the pools, e.g. the one installed by
+//     mysqlprom.WithStatsCollector.
+//
+// Returns:
+//   - An Option function that enables the registration when applied.
+//
+// Example:
+//
+//	m, err := NewManager(WithConfigs(cfg1, cfg2), mysqlprom.WithStatsCollector(prometheus.DefaultRegisterer))
+func WithStatsRegistry(reg StatsRegistry) Option {
+	return func(o *option) {
+		o.statsRegistry = reg
+	}
+}
+
+// registerStats registers the given pools with the stats registry if it is enabled.
+//
+// Parameters:
+//   - opt: A pointer to the option struct holding the registry.
+//   - pools: The function returning the statistics of the pools at call time.
+//
+// Returns:
+//   - An error if the pools cannot be registered.
+func registerStats(opt *option, pools func() []PoolStats) error {
+	if opt.statsRegistry == nil {
+		return nil
+	}
+
+	return opt.statsRegistry.RegisterPools(pools)
+}
+
+// collectPoolStats returns the statistics of the given connections and of their read replicas.
+//
+// Parameters:
+//   - dbs: The connections, keyed by name.
+//   - cfgs: The configurations of the connections, keyed by name.
+//
+// Returns:
+//   - The statistics sorted by connection name.
+func collectPoolStats(dbs map[string]*gorm.DB, cfgs map[string]Config) []PoolStats {
+	pools := make([]PoolStats, 0, len(dbs))
+	for name, db := range dbs {
+		sqlDB, err := db.DB()
+		if err != nil {
+			continue
+		}
+
+		cfg := cfgs[name]
+		pools = append(pools, PoolStats{Connection: name, Host: cfg.host(), Stats: sqlDB.Stats()})
+
+		if r, ok := db.Config.Plugins[resolverPluginName].(*resolver); ok {
+			for _, rep := range r.replicas {
+				pools = append(pools, PoolStats{Connection: rep.name, Host: rep.host, Stats: rep.sqlDB.Stats()})
+			}
+		}
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Connection < pools[j].Connection })
+
+	return pools
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// recordingRegistry is a StatsRegistry keeping every registered set of pools.
+type recordingRegistry struct {
+	pools []func() []PoolStats
+}
+
+func (r *recordingRegistry) RegisterPools(pools func() []PoolStats) error {
+	r.pools = append(r.pools, pools)
+	return nil
+}
+
+// connections returns the connection and host of every pool of a registered set.
+func (r *recordingRegistry) connections(i int) map[string]string {
+	connections := make(map[string]string)
+	for _, p := range r.pools[i]() {
+		connections[p.Connection] = p.Host
+	}
+
+	return connections
+}
+
+func TestStatsRegistry(t *testing.T) {
+	reg := &recordingRegistry{}
+
+	cfg := Config{User: "user", Host: "127.0.0.1:1", DBName: "orders",
+		Replicas: []Config{{Host: "127.0.0.2:1"}}}
+	m, err := NewManager(WithConfigs(cfg), WithLazyConnect(), WithMaxOpenConn(7), WithStatsRegistry(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"orders": "127.0.0.1:1", "orders-replica-1": "127.0.0.2:1"}
+	if got := reg.connections(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("pools = %v, want %v", got, want)
+	}
+
+	if got := reg.pools[0]()[0].Stats.MaxOpenConnections; got != 7 {
+		t.Errorf("max open connections = %d, want 7", got)
+	}
+
+	db, err := New(WithConfigs(Config{User: "user", Host: "127.0.0.3:1", DBName: "users"}), WithLazyConnect(), WithStatsRegistry(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = Close(db) }()
+
+	if got := reg.connections(1); !reflect.DeepEqual(got, map[string]string{"users": "127.0.0.3:1"}) {
+		t.Errorf("pools = %v", got)
+	}
+
+	_ = m.Close(context.Background())
+	if got := reg.pools[0](); len(got) != 0 {
+		t.Errorf("closed Manager is still reported: %v", got)
+	}
+}
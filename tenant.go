@@ -0,0 +1,559 @@
+package mysql
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxTenants is the default number of tenant pools kept open.
+	defaultMaxTenants = 100
+	// defaultTenantIdleTimeout is the default time after which an unused tenant pool is closed.
+	defaultTenantIdleTimeout = 10 * time.Minute
+	// defaultTenantMetricsName is the connection reported by the metrics plugin of tenant
+	// pools when the template has no name.
+	defaultTenantMetricsName = "tenants"
+)
+
+var (
+	// ErrNoTenant is returned by TenantResolver.DB when the context carries no tenant id.
+	ErrNoTenant = errors.New("context carries no tenant id")
+	// ErrInvalidTenant is returned for tenant ids that are not valid database name parts.
+	ErrInvalidTenant = errors.New("invalid tenant id")
+	// ErrTenantResolverClosed is returned by TenantResolver methods after Close has been called.
+	ErrTenantResolverClosed = errors.New("tenant resolver is closed")
+)
+
+// tenantIDPattern restricts tenant ids to characters that are safe in database names.
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tenantKey is the context key under which the tenant id is stored.
+type tenantKey struct{}
+
+// tenantOption holds the settings of a TenantResolver.
+type tenantOption struct {
+	maxTenants  int                        // Maximum number of open tenant pools
+	idleTimeout time.Duration              // Time after which an unused tenant pool is closed
+	dbName      func(tenant string) string // Maps a tenant id to its database name
+}
+
+// WithMaxTenants returns an Option that bounds the number of tenant pools a
+// TenantResolver keeps open. When the bound is reached, the least recently used pool
+// that is not leased is closed.
+//
+// Parameters:
+//   - n: The maximum number of open tenant pools.
+//
+// Returns:
+//   - An Option function that sets the bound when applied.
+//
+// Example:
+//
+//	tenants, err := NewTenantResolver(template, WithMaxTenants(200), WithMaxOpenConn(5))
+func WithMaxTenants(n int) Option {
+	return func(o *option) {
+		o.tenant.maxTenants = n
+	}
+}
+
+// WithTenantIdleTimeout returns an Option that sets how long a tenant pool may stay unused
+// before the TenantResolver closes it.
+//
+// Parameters:
+//   - d: The idle timeout.
+//
+// Returns:
+//   - An Option function that sets the idle timeout when applied.
+//
+// Example:
+//
+//	tenants, err := NewTenantResolver(template, WithTenantIdleTimeout(5*time.Minute))
+func WithTenantIdleTimeout(d time.Duration) Option {
+	return func(o *option) {
+		o.tenant.idleTimeout = d
+	}
+}
+
+// WithTenantDBName returns an Option that sets how a TenantResolver derives the database
+// name of a tenant. By default the tenant id is the database name.
+//
+// Parameters:
+//   - dbName: The function mapping a tenant id to its database name.
+//
+// Returns:
+//   - An Option function that sets the mapping when applied.
+//
+// Example:
+//
+//	tenants, err := NewTenantResolver(template, WithTenantDBName(func(tenant string) string {
+//	    return "tenant_" + tenant
+//	}))
+func WithTenantDBName(dbName func(tenant string) string) Option {
+	return func(o *option) {
+		o.tenant.dbName = dbName
+	}
+}
+
+// WithTenant returns a copy of ctx carrying the tenant id used by TenantResolver.DB.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - tenant: The tenant id.
+//
+// Returns:
+//   - A context carrying the tenant id.
+//
+// Example:
+//
+//	ctx := WithTenant(r.Context(), r.Header.Get("X-Tenant"))
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant id carried by ctx.
+//
+// Parameters:
+//   - ctx: The context.
+//
+// Returns:
+//   - The tenant id.
+//   - A boolean indicating whether ctx carries a non-empty tenant id.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// tenantPool is a tenant connection pool cached by a TenantResolver.
+type tenantPool struct {
+	tenant   string
+	db       *gorm.DB
+	err      error
+	ready    chan struct{} // Closed once db or err is set
+	lastUsed time.Time
+	leases   int // Number of unreleased leases, guarded by TenantResolver.mu
+}
+
+// TenantResolver opens one connection pool per tenant database on demand from a template
+// configuration and caches the pools, closing the least recently used and idle ones so
+// that many tenants do not exhaust the server's connections. A pool is never closed while
+// a caller holds a lease on it, see Get.
+type TenantResolver struct {
+	opt      *option
+	template Config
+
+	mu     sync.Mutex
+	pools  map[string]*list.Element // Values are *tenantPool
+	lru    *list.List               // Most recently used first
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewTenantResolver returns a TenantResolver deriving the configuration of each tenant
+// from template by replacing its database name. It accepts the same options as New; pool
+// settings apply to every tenant pool.
+//
+// Parameters:
+//   - template: The configuration shared by every tenant, e.g. host and credentials.
+//   - opts: A variadic list of Option functions, including WithMaxTenants,
+//     WithTenantIdleTimeout and WithTenantDBName.
+//
+// Returns:
+//   - A pointer to the TenantResolver. No pool is opened until a tenant is requested.
+//   - An error if the template DSN is invalid.
+//
+// Example:
+//
+//	tenants, err := NewTenantResolver(Config{User: "app", Password: "secret", Host: "127.0.0.1:3306"},
+//	    WithMaxTenants(500), WithMaxOpenConn(5))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer tenants.Close(context.Background())
+//
+//	db, release, err := tenants.DB(WithTenant(ctx, "acme"))
+//	if err != nil {
+//	    return err
+//	}
+//	defer release()
+func NewTenantResolver(template Config, opts ...Option) (*TenantResolver, error) {
+	opt := setOption(opts...)
+	if opt.tenant.maxTenants <= 0 {
+		opt.tenant.maxTenants = defaultMaxTenants
+	}
+
+	if opt.tenant.idleTimeout <= 0 {
+		opt.tenant.idleTimeout = defaultTenantIdleTimeout
+	}
+
+	// Report every tenant under one connection so that metrics series stay bounded
+	opt.metricsName = template.Name
+	if opt.metricsName == "" {
+		opt.metricsName = defaultTenantMetricsName
+	}
+
+	if template.DSN != "" {
+		if _, err := gomysql.ParseDSN(template.DSN); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &TenantResolver{
+		opt:      opt,
+		template: template,
+		pools:    make(map[string]*list.Element),
+		lru:      list.New(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go r.run()
+
+	return r, nil
+}
+
+// DB returns the connection of the tenant carried by ctx, bound to ctx, together with a
+// lease on its pool. The pool is opened on first use and is not evicted while it is leased,
+// so call release once the request no longer uses the gorm.DB.
+//
+// Parameters:
+//   - ctx: The request context carrying the tenant id, see WithTenant.
+//
+// Returns:
+//   - The gorm.DB of the tenant.
+//   - The function releasing the lease; calling it more than once has no effect.
+//   - ErrNoTenant, ErrInvalidTenant, ErrTenantResolverClosed or the connection error.
+//
+// Example:
+//
+//	db, release, err := tenants.DB(r.Context())
+//	if err != nil {
+//	    return err
+//	}
+//	defer release()
+func (r *TenantResolver) DB(ctx context.Context) (*gorm.DB, func(), error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, nil, ErrNoTenant
+	}
+
+	db, release, err := r.Get(ctx, tenant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db.WithContext(ctx), release, nil
+}
+
+// Get returns the connection of the given tenant together with a lease on its pool, opening
+// the pool on first use. The pool is not evicted until every lease has been released.
+//
+// Parameters:
+//   - ctx: The context bounding the connection attempts.
+//   - tenant: The tenant id.
+//
+// Returns:
+//   - The gorm.DB of the tenant.
+//   - The function releasing the lease; calling it more than once has no effect.
+//   - ErrInvalidTenant, ErrTenantResolverClosed or the connection error.
+func (r *TenantResolver) Get(ctx context.Context, tenant string) (*gorm.DB, func(), error) {
+	if !tenantIDPattern.MatchString(tenant) {
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidTenant, tenant)
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, nil, ErrTenantResolverClosed
+	}
+
+	if el, ok := r.pools[tenant]; ok {
+		p := el.Value.(*tenantPool)
+		p.lastUsed = time.Now()
+		p.leases++
+		r.lru.MoveToFront(el)
+		r.mu.Unlock()
+
+		<-p.ready
+		if p.err != nil {
+			r.release(p)
+			return nil, nil, fmt.Errorf("connect tenant %q: %w", tenant, p.err)
+		}
+
+		return p.db, r.lease(p), nil
+	}
+
+	p := &tenantPool{tenant: tenant, ready: make(chan struct{}), lastUsed: time.Now(), leases: 1}
+	r.pools[tenant] = r.lru.PushFront(p)
+	evicted := r.evictLocked(func(p *tenantPool) bool { return len(r.pools) > r.opt.tenant.maxTenants })
+	r.mu.Unlock()
+
+	r.closePools(evicted)
+
+	cfg, err := r.config(tenant)
+	if err == nil {
+		p.db, err = newConnect(ctx, &cfg, r.opt)
+	}
+	p.err = err
+	close(p.ready)
+
+	if err != nil {
+		// Forget the failed pool so that the next request tries again
+		r.mu.Lock()
+		p.leases--
+		if el, ok := r.pools[tenant]; ok && el.Value == p {
+			r.lru.Remove(el)
+			delete(r.pools, tenant)
+		}
+		r.mu.Unlock()
+
+		return nil, nil, fmt.Errorf("connect tenant %q: %w", tenant, err)
+	}
+
+	return p.db, r.lease(p), nil
+}
+
+// lease returns the function releasing a lease taken on a pool.
+//
+// Parameters:
+//   - p: The leased pool.
+//
+// Returns:
+//   - The release function, which only has an effect the first time it is called.
+func (r *TenantResolver) lease(p *tenantPool) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { r.release(p) })
+	}
+}
+
+// release returns a lease on a pool and evicts pools that were kept beyond the bound only
+// because they were leased.
+//
+// Parameters:
+//   - p: The leased pool.
+func (r *TenantResolver) release(p *tenantPool) {
+	r.mu.Lock()
+	p.leases--
+	p.lastUsed = time.Now()
+
+	var evicted []*tenantPool
+	if !r.closed {
+		evicted = r.evictLocked(func(*tenantPool) bool { return len(r.pools) > r.opt.tenant.maxTenants })
+	}
+	r.mu.Unlock()
+
+	r.closePools(evicted)
+}
+
+// Tenants returns the ids of the tenants whose pools are open, in sorted order.
+//
+// Returns:
+//   - The tenant ids.
+func (r *TenantResolver) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenants := make([]string, 0, len(r.pools))
+	for tenant := range r.pools {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	return tenants
+}
+
+// Close stops handing out tenant pools and closes them once every lease has been released.
+// Release calls made after Close are harmless. If ctx is done before the last lease is
+// released, the pools are closed anyway in the background, failing the queries of the
+// remaining lease holders, and the context error is returned.
+//
+// Parameters:
+//   - ctx: The context bounding how long Close waits for leases.
+//
+// Returns:
+//   - The joined errors of every pool that failed to close, ErrTenantResolverClosed if
+//     Close was already called, or the context error.
+func (r *TenantResolver) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return ErrTenantResolverClosed
+	}
+
+	r.closed = true
+	pools := make([]*tenantPool, 0, len(r.pools))
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		pools = append(pools, el.Value.(*tenantPool))
+	}
+	r.pools = make(map[string]*list.Element)
+	r.lru.Init()
+	r.mu.Unlock()
+
+	close(r.stop)
+	<-r.done
+
+	for r.leased(pools) {
+		select {
+		case <-ctx.Done():
+			go func() { _ = r.closePools(pools) }()
+			return ctx.Err()
+		case <-time.After(drainPollInterval):
+		}
+	}
+
+	return r.closePools(pools)
+}
+
+// leased reports whether any of the given pools still has an unreleased lease.
+//
+// Parameters:
+//   - pools: The pools to check.
+//
+// Returns:
+//   - true if a lease is outstanding.
+func (r *TenantResolver) leased(pools []*tenantPool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range pools {
+		if p.leases > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// config returns the configuration of a tenant.
+//
+// Parameters:
+//   - tenant: The tenant id.
+//
+// Returns:
+//   - The template with the tenant's database name and connection name.
+//   - An error if the template DSN cannot be rewritten.
+func (r *TenantResolver) config(tenant string) (Config, error) {
+	dbName := tenant
+	if r.opt.tenant.dbName != nil {
+		dbName = r.opt.tenant.dbName(tenant)
+	}
+
+	cfg := r.template
+	cfg.Name = tenant
+	cfg.DBName = dbName
+
+	if cfg.DSN != "" {
+		dc, err := gomysql.ParseDSN(cfg.DSN)
+		if err != nil {
+			return Config{}, err
+		}
+
+		dc.DBName = dbName
+		cfg.DSN = dc.FormatDSN()
+	}
+
+	return cfg, nil
+}
+
+// run closes idle tenant pools until the resolver is closed.
+func (r *TenantResolver) run() {
+	defer close(r.done)
+
+	interval := r.opt.tenant.idleTimeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.evictIdle()
+		}
+	}
+}
+
+// evictIdle closes the pools that have not been used for the idle timeout.
+func (r *TenantResolver) evictIdle() {
+	deadline := time.Now().Add(-r.opt.tenant.idleTimeout)
+
+	r.mu.Lock()
+	evicted := r.evictLocked(func(p *tenantPool) bool { return p.lastUsed.Before(deadline) })
+	r.mu.Unlock()
+
+	r.closePools(evicted)
+}
+
+// evictLocked removes pools from the cache, starting from the least recently used one, while
+// want reports true. Leased pools, including those still opening, are skipped. The caller
+// must hold r.mu and close the returned pools after releasing it.
+//
+// Parameters:
+//   - want: Reports whether the given pool, the least recently used candidate, should be evicted.
+//
+// Returns:
+//   - The evicted pools.
+func (r *TenantResolver) evictLocked(want func(p *tenantPool) bool) []*tenantPool {
+	var evicted []*tenantPool
+	for el := r.lru.Back(); el != nil; {
+		prev := el.Prev()
+		p := el.Value.(*tenantPool)
+
+		if !want(p) {
+			break
+		}
+
+		if p.busy() {
+			el = prev
+			continue
+		}
+
+		r.lru.Remove(el)
+		delete(r.pools, p.tenant)
+		evicted = append(evicted, p)
+		el = prev
+	}
+
+	return evicted
+}
+
+// busy reports whether the pool is leased. The caller must hold TenantResolver.mu.
+func (p *tenantPool) busy() bool {
+	return p.leases > 0
+}
+
+// closePools closes the given tenant pools.
+//
+// Parameters:
+//   - pools: The pools to close.
+//
+// Returns:
+//   - The joined errors of every pool that failed to close, each naming its tenant.
+func (r *TenantResolver) closePools(pools []*tenantPool) error {
+	var errs []error
+	for _, p := range pools {
+		<-p.ready
+		if p.db == nil {
+			continue
+		}
+
+		if err := closeDB(p.db); err != nil {
+			errs = append(errs, fmt.Errorf("close tenant %q: %w", p.tenant, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,177 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTenantResolver(t *testing.T) {
+	template := Config{User: "app", Host: "127.0.0.1:1"}
+	r, err := NewTenantResolver(template, WithLazyConnect(), WithMaxTenants(2),
+		WithTenantDBName(func(tenant string) string { return "tenant_" + tenant }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = r.DB(context.Background()); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("expected ErrNoTenant, got %v", err)
+	}
+
+	if _, _, err = r.DB(WithTenant(context.Background(), "acme`; DROP")); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("expected ErrInvalidTenant, got %v", err)
+	}
+
+	acme, release, err := r.DB(WithTenant(context.Background(), "acme"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, releaseAgain, err := r.Get(context.Background(), "acme")
+	if err != nil || again.ConnPool != acme.ConnPool {
+		t.Fatalf("pool was not cached: %v", err)
+	}
+	release()
+	releaseAgain()
+
+	for _, tenant := range []string{"globex", "initech"} {
+		_, release, err := r.Get(context.Background(), tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+
+	if got := r.Tenants(); !reflect.DeepEqual(got, []string{"globex", "initech"}) {
+		t.Errorf("Tenants() = %v, want the two most recently used", got)
+	}
+
+	sqlDB, _ := acme.DB()
+	if !poolClosed(sqlDB) {
+		t.Error("evicted pool was not closed")
+	}
+
+	if err = r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = r.Get(context.Background(), "acme"); !errors.Is(err, ErrTenantResolverClosed) {
+		t.Errorf("expected ErrTenantResolverClosed, got %v", err)
+	}
+}
+
+func TestTenantResolverLease(t *testing.T) {
+	r, err := NewTenantResolver(Config{User: "app", Host: "127.0.0.1:1"}, WithLazyConnect(), WithMaxTenants(1),
+		WithTenantIdleTimeout(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acme, release, err := r.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := acme.DB()
+
+	_, releaseGlobex, err := r.Get(context.Background(), "globex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseGlobex()
+
+	time.Sleep(5 * time.Millisecond)
+	r.evictIdle()
+
+	if got := r.Tenants(); !reflect.DeepEqual(got, []string{"acme"}) || poolClosed(sqlDB) {
+		t.Fatalf("Tenants() = %v, the leased pool must be kept open", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err = r.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() = %v, want context.DeadlineExceeded while a lease is held", err)
+	}
+
+	release()
+	release()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !poolClosed(sqlDB) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if !poolClosed(sqlDB) {
+		t.Error("pool was not closed after Close")
+	}
+}
+
+func TestTenantResolverCloseWaitsForLeases(t *testing.T) {
+	r, err := NewTenantResolver(Config{User: "app", Host: "127.0.0.1:1"}, WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acme, release, err := r.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := acme.DB()
+
+	done := make(chan error, 1)
+	go func() { done <- r.Close(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	if poolClosed(sqlDB) {
+		t.Fatal("leased pool was closed before its lease was released")
+	}
+
+	release()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if !poolClosed(sqlDB) {
+		t.Error("pool was not closed after its lease was released")
+	}
+}
+
+func TestTenantResolverIdle(t *testing.T) {
+	r, err := NewTenantResolver(Config{User: "app", Host: "127.0.0.1:1"}, WithLazyConnect(), WithTenantIdleTimeout(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close(context.Background()) }()
+
+	_, release, err := r.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	time.Sleep(5 * time.Millisecond)
+	r.evictIdle()
+
+	if got := r.Tenants(); len(got) != 0 {
+		t.Errorf("idle pools were not closed: %v", got)
+	}
+}
+
+func TestTenantResolverConfig(t *testing.T) {
+	r, err := NewTenantResolver(Config{DSN: "app:secret@tcp(127.0.0.1:3306)/template?parseTime=true"}, WithLazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close(context.Background()) }()
+
+	cfg, err := r.config("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.key() != "acme" || cfg.dbName() != "acme" || cfg.host() != "127.0.0.1:3306" {
+		t.Errorf("unexpected tenant config: name %q, db %q, host %q", cfg.key(), cfg.dbName(), cfg.host())
+	}
+}
@@ -0,0 +1,242 @@
+package mysql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	gomysql "github.com/go-sql-driver/mysql"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TLSMode selects how the driver negotiates TLS with the server.
+type TLSMode string
+
+const (
+	// TLSModeDisabled disables TLS.
+	TLSModeDisabled TLSMode = ""
+	// TLSModeRequired requires TLS and verifies the server certificate.
+	TLSModeRequired TLSMode = "true"
+	// TLSModeSkipVerify requires TLS but does not verify the server certificate.
+	TLSModeSkipVerify TLSMode = "skip-verify"
+	// TLSModePreferred uses TLS when the server supports it and falls back to plaintext otherwise.
+	// The server certificate is only verified when TLSConfig.CAFile or TLSConfig.ServerName
+	// is set; without them it is not verified.
+	TLSModePreferred TLSMode = "preferred"
+)
+
+// tlsRegistry remembers the driver registration of every distinct TLS setting so that
+// configurations rebuilt with the same settings, such as by Manager.Reload, share a single
+// registration instead of adding a new one each time.
+var tlsRegistry = struct {
+	sync.Mutex
+	entries map[tlsKey]tlsRegistration
+	seq     int
+}{entries: make(map[tlsKey]tlsRegistration)}
+
+// tlsKey identifies the settings of a TLSConfig. The base tls.Config is compared by pointer.
+type tlsKey struct {
+	mode       TLSMode
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string
+	config     *tls.Config
+}
+
+// tlsRegistration is a tls.Config registered with the driver.
+type tlsRegistration struct {
+	name  string // Name the tls.Config is registered under
+	stamp string // Modification times and sizes of the files it was built from
+}
+
+// TLSConfig describes the TLS settings for a MySQL connection.
+//
+// When only Mode is set, the driver's built-in mode of the same name is used. When a CA,
+// a client certificate or a custom tls.Config is provided, a tls.Config is built from them
+// and registered with the driver under a unique name.
+type TLSConfig struct {
+	Mode       TLSMode     // TLS mode, defaults to TLSModeRequired when certificates or Config are set
+	CAFile     string      // Path to a PEM encoded CA bundle used to verify the server
+	CertFile   string      // Path to a PEM encoded client certificate for mutual TLS
+	KeyFile    string      // Path to the PEM encoded private key of the client certificate
+	ServerName string      // Server name used for certificate verification, defaults to the host
+	Config     *tls.Config // Base tls.Config, cloned before CA and client certificates are applied
+}
+
+// WithTLS returns an Option that sets the default TLS configuration for every database
+// configuration that does not declare its own Config.TLS.
+//
+// Parameters:
+//   - cfg: A pointer to a TLSConfig describing the TLS settings.
+//
+// Returns:
+//   - An Option function that sets the default TLS configuration when applied.
+//
+// Example:
+//
+//	db, err := New(
+//	    WithConfigs(cfg),
+//	    WithTLS(&TLSConfig{CAFile: "/etc/mysql/ca.pem", CertFile: "/etc/mysql/client.pem", KeyFile: "/etc/mysql/client-key.pem"}),
+//	)
+func WithTLS(cfg *TLSConfig) Option {
+	return func(o *option) {
+		o.tls = cfg
+	}
+}
+
+// custom reports whether the TLSConfig needs a registered tls.Config rather than one of
+// the driver's built-in modes.
+func (t *TLSConfig) custom() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.ServerName != "" || t.Config != nil
+}
+
+// apply wires the TLS settings into the driver configuration.
+//
+// Parameters:
+//   - dc: The driver configuration to update.
+//
+// Returns:
+//   - An error if the certificates cannot be loaded or the mode is unknown.
+func (t *TLSConfig) apply(dc *gomysql.Config) error {
+	switch t.Mode {
+	case TLSModeDisabled, TLSModeRequired, TLSModeSkipVerify, TLSModePreferred:
+	default:
+		return fmt.Errorf("unknown TLS mode %q", t.Mode)
+	}
+
+	if !t.custom() {
+		dc.TLSConfig = string(t.Mode)
+		return nil
+	}
+
+	name, err := t.register()
+	if err != nil {
+		return err
+	}
+
+	dc.TLSConfig = name
+	if t.Mode == TLSModePreferred {
+		dc.AllowFallbackToPlaintext = true
+	}
+
+	return nil
+}
+
+// register builds the tls.Config and registers it with the driver. The registration of
+// equal settings is reused as long as the certificate files are unchanged; when they have
+// been rewritten, the tls.Config is rebuilt and registered again under the same name, so
+// that pools opened afterwards use the rotated certificates.
+//
+// Returns:
+//   - The name under which the tls.Config is registered.
+//   - An error if the certificates cannot be loaded.
+func (t *TLSConfig) register() (string, error) {
+	tlsRegistry.Lock()
+	defer tlsRegistry.Unlock()
+
+	key := tlsKey{
+		mode:       t.Mode,
+		caFile:     t.CAFile,
+		certFile:   t.CertFile,
+		keyFile:    t.KeyFile,
+		serverName: t.ServerName,
+		config:     t.Config,
+	}
+	stamp := t.stamp()
+
+	reg, ok := tlsRegistry.entries[key]
+	if ok && reg.stamp == stamp {
+		return reg.name, nil
+	}
+
+	tc, err := t.build()
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		tlsRegistry.seq++
+		reg.name = fmt.Sprintf("sk-pkg-mysql-tls-%d", tlsRegistry.seq)
+	}
+
+	if err = gomysql.RegisterTLSConfig(reg.name, tc); err != nil {
+		return "", err
+	}
+
+	reg.stamp = stamp
+	tlsRegistry.entries[key] = reg
+	return reg.name, nil
+}
+
+// stamp describes the current version of the certificate files.
+//
+// Returns:
+//   - The modification time and size of every file; files that cannot be read are left
+//     to build to report.
+func (t *TLSConfig) stamp() string {
+	var b strings.Builder
+	for _, path := range []string{t.CAFile, t.CertFile, t.KeyFile} {
+		if path == "" {
+			continue
+		}
+
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", path, info.ModTime().UnixNano(), info.Size())
+		}
+	}
+
+	return b.String()
+}
+
+// build creates the tls.Config described by the TLSConfig.
+//
+// Returns:
+//   - A pointer to the resulting tls.Config.
+//   - An error if the CA bundle or the client key pair cannot be loaded.
+func (t *TLSConfig) build() (*tls.Config, error) {
+	tc := &tls.Config{}
+	if t.Config != nil {
+		tc = t.Config.Clone()
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read TLS CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", t.CAFile)
+		}
+
+		tc.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, errors.New("TLS client certificate and key must be set together")
+		}
+
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS client certificate: %w", err)
+		}
+
+		tc.Certificates = append(tc.Certificates, cert)
+	}
+
+	if t.ServerName != "" {
+		tc.ServerName = t.ServerName
+	}
+
+	// A preferred connection given a CA or server name verifies the certificate with them
+	if t.Mode == TLSModeSkipVerify || (t.Mode == TLSModePreferred && t.CAFile == "" && t.ServerName == "") {
+		tc.InsecureSkipVerify = true
+	}
+
+	return tc, nil
+}
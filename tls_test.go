@@ -0,0 +1,121 @@
+package mysql
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	gomysql "github.com/go-sql-driver/mysql"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCAFile writes a self-signed PEM certificate to path.
+func writeCAFile(t *testing.T, path string, serial int64) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSConfigApply(t *testing.T) {
+	dc := gomysql.NewConfig()
+	if err := (&TLSConfig{Mode: TLSModeSkipVerify}).apply(dc); err != nil {
+		t.Fatal(err)
+	}
+
+	if dc.TLSConfig != "skip-verify" {
+		t.Errorf("tls = %q, want %q", dc.TLSConfig, "skip-verify")
+	}
+
+	custom := &TLSConfig{Mode: TLSModePreferred, Config: &tls.Config{MinVersion: tls.VersionTLS12}}
+	first, second := gomysql.NewConfig(), gomysql.NewConfig()
+	if err := custom.apply(first); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := custom.apply(second); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(first.TLSConfig, "sk-pkg-mysql-tls-") || first.TLSConfig != second.TLSConfig {
+		t.Errorf("unexpected registration names %q and %q", first.TLSConfig, second.TLSConfig)
+	}
+
+	if !first.AllowFallbackToPlaintext {
+		t.Error("preferred mode should allow fallback to plaintext")
+	}
+
+	if tc, err := (&TLSConfig{Mode: TLSModePreferred}).build(); err != nil || !tc.InsecureSkipVerify {
+		t.Errorf("preferred mode without a CA should not verify the certificate: %v", err)
+	}
+
+	if tc, err := (&TLSConfig{Mode: TLSModePreferred, ServerName: "db.internal"}).build(); err != nil || tc.InsecureSkipVerify {
+		t.Errorf("preferred mode with a server name should verify the certificate: %v", err)
+	}
+
+	if err := (&TLSConfig{Mode: "bogus"}).apply(gomysql.NewConfig()); err == nil {
+		t.Error("expected an error for an unknown TLS mode")
+	}
+}
+
+func TestTLSConfigRegistryReuse(t *testing.T) {
+	path := writeConfigFile(t, "ca.pem", "")
+	writeCAFile(t, path, 1)
+
+	first, second := gomysql.NewConfig(), gomysql.NewConfig()
+	if err := (&TLSConfig{CAFile: path}).apply(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// A rebuilt TLSConfig with the same settings shares the registration
+	if err := (&TLSConfig{CAFile: path}).apply(second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.TLSConfig != second.TLSConfig {
+		t.Errorf("equal settings registered twice: %q and %q", first.TLSConfig, second.TLSConfig)
+	}
+
+	key := tlsKey{caFile: path}
+	before := tlsRegistry.entries[key].stamp
+
+	writeCAFile(t, path, 2)
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(path, later, later)
+
+	third := gomysql.NewConfig()
+	if err := (&TLSConfig{CAFile: path}).apply(third); err != nil {
+		t.Fatal(err)
+	}
+
+	if third.TLSConfig != first.TLSConfig || tlsRegistry.entries[key].stamp == before {
+		t.Errorf("rotated CA file was not registered again under %q: got %q", first.TLSConfig, third.TLSConfig)
+	}
+}